@@ -0,0 +1,28 @@
+package v1alpha
+
+// Condition types shared by VPCs and VPCAttachments.
+const (
+	// ConditionReady indicates whether the resource is ready for use.
+	ConditionReady = "Ready"
+
+	// ConditionIdentifierAssigned indicates whether a unique identifier has been assigned to the resource.
+	ConditionIdentifierAssigned = "IdentifierAssigned"
+)
+
+// Condition reasons shared by VPCs and VPCAttachments.
+const (
+	// ReasonReady is the reason for a Ready condition of a resource that is ready for use.
+	ReasonReady = "Ready"
+
+	// ReasonIdentifierAssigned is the reason for an IdentifierAssigned condition once an identifier has been assigned.
+	ReasonIdentifierAssigned = "IdentifierAssigned"
+
+	// ReasonIdentifiersExhausted is the reason for conditions caused by failing to find an unused identifier.
+	ReasonIdentifiersExhausted = "IdentifiersExhausted"
+
+	// ReasonInvalidIdentifier is the reason for conditions caused by a requested identifier that cannot be used.
+	ReasonInvalidIdentifier = "InvalidIdentifier"
+
+	// ReasonIdentifierConflict is the reason for conditions caused by an identifier claimed by another resource.
+	ReasonIdentifierConflict = "IdentifierConflict"
+)
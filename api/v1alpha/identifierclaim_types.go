@@ -0,0 +1,62 @@
+package v1alpha
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IdentifierClaimClaimantLabel carries the UID of the resource holding an IdentifierClaim.
+const IdentifierClaimClaimantLabel = "galactic.datumapis.com/claimant-uid"
+
+// IdentifierClaimScopeLabel carries the scope an identifier is unique in: "vpc" for VPC identifiers,
+// "vpcattachment-<vpc identifier>" for the identifiers of the VPCAttachments of a VPC and
+// "vpcaddress-<vpc identifier>" for the addresses allocated to the VPCAttachments of a VPC.
+const IdentifierClaimScopeLabel = "galactic.datumapis.com/identifier-scope"
+
+// IdentifierClaimSpec defines the desired state of an IdentifierClaim
+type IdentifierClaimSpec struct {
+	// The claimed identifier in its canonical hexadecimal representation. Addresses are
+	// claimed by the hexadecimal representation of their bytes.
+	// +required
+	Identifier string `json:"identifier"`
+
+	// The VPC or VPCAttachment holding the identifier
+	// +required
+	Claimant corev1.ObjectReference `json:"claimant"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Identifier",type=string,JSONPath=`.spec.identifier`
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.claimant.kind`
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.claimant.namespace`
+// +kubebuilder:printcolumn:name="Name",type=string,JSONPath=`.spec.claimant.name`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// IdentifierClaim reserves an identifier or an address for a VPC or
+// VPCAttachment. The name of the claim is derived from the identifier, so the
+// API server guarantees that every identifier is held by at most one resource.
+type IdentifierClaim struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of an IdentifierClaim
+	// +required
+	Spec IdentifierClaimSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// IdentifierClaimList contains a list of IdentifierClaims
+type IdentifierClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IdentifierClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IdentifierClaim{}, &IdentifierClaimList{})
+}
@@ -0,0 +1,121 @@
+package v1alpha
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TransitGatewayReasonRouteTableNotFound is the reason for a Ready condition of a TransitGateway with VPCs
+	// associated with a route table it does not have.
+	TransitGatewayReasonRouteTableNotFound = "RouteTableNotFound"
+	// TransitGatewayReasonNetworksOverlap is the reason for a Ready condition of a TransitGateway propagating
+	// overlapping networks into a route table.
+	TransitGatewayReasonNetworksOverlap = "NetworksOverlap"
+	// TransitGatewayReasonAssociationNotPermitted is the reason for a Ready condition of a TransitGateway with
+	// VPCs associated with a route table that does not select their namespace.
+	TransitGatewayReasonAssociationNotPermitted = "AssociationNotPermitted"
+)
+
+// TransitGatewaySpec defines the desired state of a TransitGateway
+type TransitGatewaySpec struct {
+	// The route tables of the TransitGateway, VPCs associate with one of them
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	// +required
+	RouteTables []TransitGatewayRouteTable `json:"routeTables"`
+}
+
+// TransitGatewayRouteTable decides which VPCs the VPCs associated with it reach.
+type TransitGatewayRouteTable struct {
+	// Name of the route table
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Name string `json:"name"`
+
+	// Names of the route tables whose associated VPCs propagate their networks into this route
+	// table. The VPCs associated with this route table reach the VPCs associated with the listed
+	// route tables, which may include this route table itself.
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	PropagateFrom []string `json:"propagateFrom,omitempty"`
+
+	// Selects the namespaces whose VPCs may associate with the route table. An empty selector
+	// selects all namespaces. If unset, no VPC may associate with the route table. VPCs of other
+	// namespaces are neither reachable nor reach any VPC through the TransitGateway.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// TransitGatewayStatus defines the observed state of a TransitGateway
+type TransitGatewayStatus struct {
+	// Indicates whether all associations and propagations are in effect, mirrors the Ready condition
+	// +required
+	// +default:value=false
+	Ready bool `json:"ready,omitempty"`
+
+	// A machine-readable explanation of the Ready state, mirrors the reason of the Ready condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human-readable explanation of the Ready state, mirrors the message of the Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The generation of the TransitGateway the status was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The number of VPCs associated with the TransitGateway, not counting those whose namespace
+	// the route table they associate with does not select
+	// +optional
+	AssociatedVPCs int32 `json:"associatedVPCs,omitempty"`
+
+	// Conditions describing the state of the TransitGateway
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="VPCs",type=integer,JSONPath=`.status.associatedVPCs`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// TransitGateway connects the VPCs associated with it. Unlike a VPCPeering, which connects a pair
+// of VPCs, its route tables decide which of the associated VPCs reach each other.
+type TransitGateway struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of a TransitGateway
+	// +required
+	Spec TransitGatewaySpec `json:"spec"`
+
+	// status defines the observed state of a TransitGateway
+	// +optional
+	Status TransitGatewayStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// TransitGatewayList contains a list of TransitGateways
+type TransitGatewayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TransitGateway `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TransitGateway{}, &TransitGatewayList{})
+}
@@ -4,28 +4,103 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// VPCFinalizer blocks the deletion of a VPC while VPCAttachments still reference it.
+const VPCFinalizer = "galactic.datumapis.com/vpc-protection"
+
+const (
+	// VPCConditionDeletionBlocked is set while the deletion of the VPC waits for its VPCAttachments to go away.
+	VPCConditionDeletionBlocked = "DeletionBlocked"
+
+	// VPCReasonVPCAttachmentsExist is the reason for a DeletionBlocked condition caused by remaining VPCAttachments.
+	VPCReasonVPCAttachmentsExist = "VPCAttachmentsExist"
+)
+
+// VPCDeletionPolicy defines what happens to the VPCAttachments of a VPC when the VPC is deleted.
+// +kubebuilder:validation:Enum=Block;Cascade
+type VPCDeletionPolicy string
+
+const (
+	// VPCDeletionPolicyBlock keeps the VPC until all of its VPCAttachments have been deleted.
+	VPCDeletionPolicyBlock VPCDeletionPolicy = "Block"
+
+	// VPCDeletionPolicyCascade deletes the VPCAttachments of the VPC together with the VPC.
+	VPCDeletionPolicyCascade VPCDeletionPolicy = "Cascade"
+)
+
 // VPCSpec defines the desired state of a VPC
 type VPCSpec struct {
 	// A list of networks in IPv4 or IPv6 CIDR notation associated with the VPC
 	// +kubebuilder:validation:MinItems=1
 	// +kubebuilder:validation:Items=string
 	Networks []string `json:"networks"`
+
+	// What happens to the VPCAttachments of the VPC when it is deleted. Block keeps the VPC
+	// until its VPCAttachments are gone, Cascade deletes them.
+	// +kubebuilder:default=Block
+	// +optional
+	DeletionPolicy VPCDeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// A hexadecimal identifier to assign to the VPC instead of a random one, e.g. to recreate a VPC
+	// with the identifier it had before. It cannot be changed once set.
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{1,12}$`
+	// +optional
+	Identifier string `json:"identifier,omitempty"`
+
+	// The TransitGateway the VPC is associated with. The route table of the association decides
+	// which other VPCs associated with the TransitGateway the VPC reaches.
+	// +optional
+	TransitGateway *VPCTransitGatewayAssociation `json:"transitGateway,omitempty"`
+}
+
+// VPCTransitGatewayAssociation associates a VPC with a route table of a TransitGateway.
+type VPCTransitGatewayAssociation struct {
+	// Name of the TransitGateway
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Name of the route table of the TransitGateway
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	RouteTable string `json:"routeTable"`
 }
 
 // VPCStatus defines the observed state of a VPC
 type VPCStatus struct {
-	// Indicates whether the VPC is ready for use
+	// Indicates whether the VPC is ready for use, mirrors the Ready condition
 	// +required
 	// +default:value=false
 	Ready bool `json:"ready,omitempty"`
 
+	// A machine-readable explanation of the Ready state, mirrors the reason of the Ready condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human-readable explanation of the Ready state, mirrors the message of the Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The generation of the VPC the status was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// A unique identifier assigned to this VPC
 	// +optional
 	Identifier string `json:"identifier,omitempty"`
+
+	// Conditions describing the state of the VPC
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Identifier",type=string,JSONPath=`.status.identifier`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // VPC is the Schema for the vpcs API
 type VPC struct {
@@ -7,6 +7,83 @@ import (
 
 const VPCAttachmentAnnotation = "k8s.v1alpha.galactic.datumapis.com/vpc-attachment"
 
+// PodConditionVPCReady is the readiness gate of Pods attached to VPCAttachments,
+// true once their VPC interfaces are up with the requested addresses.
+const PodConditionVPCReady corev1.PodConditionType = "galactic.datumapis.com/vpc-ready"
+
+// VPCAttachedPodLabel marks the Pods attached to VPCAttachments. The Pod webhook sets it, and
+// the operator only watches and caches Pods carrying it.
+const VPCAttachedPodLabel = "galactic.datumapis.com/vpc-attached"
+
+// VPCAttachmentFinalizer blocks the deletion of a VPCAttachment while running Pods still use it.
+const VPCAttachmentFinalizer = "galactic.datumapis.com/vpcattachment-protection"
+
+// VPCAttachmentMaxAddressIndex is the largest address index a VPCAttachment may request.
+const VPCAttachmentMaxAddressIndex = 65535
+
+const (
+	// VPCAttachmentConditionConflict is set when an interface address of the VPCAttachment
+	// is already in use by another VPCAttachment of the same VPC.
+	VPCAttachmentConditionConflict = "Conflict"
+
+	// VPCAttachmentReasonAddressInUse is the reason for a Conflict condition caused by a duplicate address.
+	VPCAttachmentReasonAddressInUse = "AddressInUse"
+
+	// VPCAttachmentConditionDeletionBlocked is set while the deletion of the VPCAttachment waits for Pods using it to stop.
+	VPCAttachmentConditionDeletionBlocked = "DeletionBlocked"
+
+	// VPCAttachmentReasonPodsRunning is the reason for a DeletionBlocked condition caused by running Pods.
+	VPCAttachmentReasonPodsRunning = "PodsRunning"
+
+	// VPCAttachmentConditionVPCResolved indicates whether the referenced VPC exists and is ready.
+	VPCAttachmentConditionVPCResolved = "VPCResolved"
+
+	// VPCAttachmentReasonVPCResolved is the reason for a VPCResolved condition once the VPC is ready.
+	VPCAttachmentReasonVPCResolved = "VPCResolved"
+
+	// VPCAttachmentReasonVPCNotFound is the reason for conditions caused by a missing VPC.
+	VPCAttachmentReasonVPCNotFound = "VPCNotFound"
+
+	// VPCAttachmentReasonVPCNotReady is the reason for conditions caused by a VPC that is not ready yet.
+	VPCAttachmentReasonVPCNotReady = "VPCNotReady"
+
+	// VPCAttachmentReasonNotPermitted is the reason for conditions caused by a reference to a VPC in
+	// another namespace that no VPCAttachmentGrant allows.
+	VPCAttachmentReasonNotPermitted = "NotPermitted"
+
+	// VPCAttachmentReasonAddressAllocationFailed is the reason for a Ready condition caused by failing to allocate addresses.
+	VPCAttachmentReasonAddressAllocationFailed = "AddressAllocationFailed"
+
+	// VPCAttachmentConditionNetworkAttachmentDefinitionSynced indicates whether the NetworkAttachmentDefinition
+	// of the VPCAttachment is up to date.
+	VPCAttachmentConditionNetworkAttachmentDefinitionSynced = "NetworkAttachmentDefinitionSynced"
+
+	// VPCAttachmentReasonSynced is the reason for a NetworkAttachmentDefinitionSynced condition once it is up to date.
+	VPCAttachmentReasonSynced = "Synced"
+
+	// VPCAttachmentReasonRenderFailed is the reason for conditions caused by failing to render the CNI configuration.
+	VPCAttachmentReasonRenderFailed = "RenderFailed"
+
+	// VPCAttachmentReasonSecurityGroupNotFound is the reason for conditions caused by a reference to a
+	// missing VPCSecurityGroup.
+	VPCAttachmentReasonSecurityGroupNotFound = "SecurityGroupNotFound"
+
+	// VPCAttachmentReasonSyncFailed is the reason for conditions caused by failing to write the NetworkAttachmentDefinition.
+	VPCAttachmentReasonSyncFailed = "SyncFailed"
+)
+
+// VPCAttachmentBindingMode defines how many Pods may use a VPCAttachment at the same time.
+// +kubebuilder:validation:Enum=Exclusive;Shared
+type VPCAttachmentBindingMode string
+
+const (
+	// VPCAttachmentBindingModeExclusive allows a single running Pod to use the VPCAttachment.
+	VPCAttachmentBindingModeExclusive VPCAttachmentBindingMode = "Exclusive"
+
+	// VPCAttachmentBindingModeShared allows any number of Pods to use the VPCAttachment.
+	VPCAttachmentBindingModeShared VPCAttachmentBindingMode = "Shared"
+)
+
 // VPCAttachmentSpec defines the desired state of VPCAttachment
 type VPCAttachmentSpec struct {
 	// VPC this attachment belongs to.
@@ -20,6 +97,31 @@ type VPCAttachmentSpec struct {
 	// Routes defines additional routing entries for the VPCAttachment.
 	// +optional
 	Routes []VPCAttachmentRoute `json:"routes,omitempty"`
+
+	// Rules defines policy routing rules, e.g. to steer the traffic sourced from the
+	// addresses of the interface to the routes of a dedicated table.
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	Rules []VPCAttachmentRule `json:"rules,omitempty"`
+
+	// SecurityGroups names the VPCSecurityGroups in the namespace of the VPCAttachment that
+	// restrict its traffic. Without security groups the traffic is not restricted.
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=set
+	// +optional
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+
+	// A hexadecimal identifier to assign to the VPCAttachment instead of a random one, e.g. to recreate
+	// a VPCAttachment with the identifier it had before. It cannot be changed once set.
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{1,4}$`
+	// +optional
+	Identifier string `json:"identifier,omitempty"`
+
+	// BindingMode defines how many Pods may use the VPCAttachment at the same time. Exclusive
+	// admits a single running Pod, so that its addresses are never in use twice, Shared admits any number.
+	// +kubebuilder:default=Shared
+	// +optional
+	BindingMode VPCAttachmentBindingMode `json:"bindingMode,omitempty"`
 }
 
 // VPCAttachmentInterface defines the network interface details.
@@ -29,10 +131,38 @@ type VPCAttachmentInterface struct {
 	// +default:value="galactic0"
 	Name string `json:"name"`
 
-	// A list of IPv4 or IPv6 addresses associated with the interface.
-	// +kubebuilder:validation:MinItems=1
-	// +required
-	Addresses []string `json:"addresses"`
+	// A list of IPv4 or IPv6 addresses in CIDR notation associated with the interface.
+	// If empty, one address per address family of the VPC networks is allocated automatically.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// A list of IPv4 or IPv6 networks in CIDR notation within the VPC networks the addresses
+	// are allocated from if Addresses is empty. If empty, addresses are allocated from the VPC
+	// networks.
+	// +optional
+	AddressRanges []string `json:"addressRanges,omitempty"`
+
+	// Index of the addresses within the address ranges, counting from zero, that are allocated
+	// if Addresses is empty and no other VPCAttachment uses them. The lowest free addresses are
+	// allocated otherwise. VPCAttachments stamped out for the Pods of a StatefulSet use the
+	// ordinal of the Pod, so that it keeps its addresses when it is recreated. The index must
+	// lie within the address ranges.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	AddressIndex *int32 `json:"addressIndex,omitempty"`
+
+	// MAC address of the interface (e.g., c2:b0:57:49:47:f1).
+	// If empty, the interface gets a random MAC address.
+	// +kubebuilder:validation:Pattern=`^([0-9a-fA-F]{2}:){5}[0-9a-fA-F]{2}$`
+	// +optional
+	MAC string `json:"mac,omitempty"`
+
+	// A list of IPv4 or IPv6 gateway addresses, at most one per address family,
+	// through which the default route of the Pod is installed on this interface.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	DefaultRoute []string `json:"defaultRoute,omitempty"`
 }
 
 // VPCAttachmentRoute defines a routing entry for the VPCAttachment.
@@ -41,25 +171,140 @@ type VPCAttachmentRoute struct {
 	// +required
 	Destination string `json:"destination"`
 
-	// Via is the next hop address.
+	// Via is the next hop address. Routes without Via or NextHops are on-link routes
+	// through the VPC interface.
+	// +optional
+	Via string `json:"via"`
+
+	// NextHops spreads the traffic to the destination across several next hops in
+	// proportion to their weights. It cannot be combined with Via.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	NextHops []VPCAttachmentNextHop `json:"nextHops,omitempty"`
+
+	// Metric is the priority of the route among routes to the same destination, lower wins.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Metric int32 `json:"metric,omitempty"`
+
+	// Table is the routing table to install the route into instead of the main table.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Table int32 `json:"table,omitempty"`
+
+	// Source is the preferred source address for traffic using the route, one of the
+	// addresses of the interface.
+	// +optional
+	Source string `json:"source,omitempty"`
+}
+
+// VPCAttachmentRule defines a policy routing rule selecting the routing table for
+// traffic from or to the given networks.
+type VPCAttachmentRule struct {
+	// From is the IPv4 or IPv6 source network in CIDR notation.
+	// +optional
+	From string `json:"from,omitempty"`
+
+	// To is the IPv4 or IPv6 destination network in CIDR notation.
 	// +optional
+	To string `json:"to,omitempty"`
+
+	// Priority of the rule, rules are evaluated in increasing order of priority.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=32765
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Table is the routing table to look up for matching traffic.
+	// +kubebuilder:validation:Minimum=1
+	// +required
+	Table int32 `json:"table"`
+}
+
+// VPCAttachmentNextHop defines one of the next hops of a multipath route.
+type VPCAttachmentNextHop struct {
+	// Via is the next hop address.
+	// +required
 	Via string `json:"via"`
+
+	// Weight of the next hop relative to the other next hops of the route.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=256
+	// +kubebuilder:default=1
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // VPCAttachmentStatus defines the observed state of VPCAttachment.
 type VPCAttachmentStatus struct {
-	// Indicates whether the VPCAttachment is ready for use
+	// Indicates whether the VPCAttachment is ready for use, mirrors the Ready condition
 	// +required
 	// +default:value=false
 	Ready bool `json:"ready,omitempty"`
 
+	// A machine-readable explanation of the Ready state, mirrors the reason of the Ready condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human-readable explanation of the Ready state, mirrors the message of the Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The generation of the VPCAttachment the status was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// A unique identifier assigned to this VPCAttachment
 	// +optional
 	Identifier string `json:"identifier,omitempty"`
+
+	// The addresses in use by the interface, either taken from the spec or allocated from the VPC networks
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// The name of the running Pod bound to a VPCAttachment with the Exclusive binding mode
+	// +optional
+	BoundPod string `json:"boundPod,omitempty"`
+
+	// The routes to the networks of other VPCs, connected by a VPCPeering or reachable through a
+	// TransitGateway, that are rendered into the NetworkAttachmentDefinition
+	// +listType=atomic
+	// +optional
+	EffectiveRoutes []VPCAttachmentEffectiveRoute `json:"effectiveRoutes,omitempty"`
+
+	// Conditions describing the state of the VPCAttachment
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// VPCAttachmentEffectiveRoute is a route to a network of another VPC.
+type VPCAttachmentEffectiveRoute struct {
+	// Destination network in IPv4 or IPv6 CIDR notation
+	// +required
+	Destination string `json:"destination"`
+
+	// The VPC the destination network belongs to, in namespace/name notation
+	// +required
+	VPC string `json:"vpc"`
+
+	// Name of the TransitGateway the route was propagated through, empty for a VPCPeering
+	// +optional
+	TransitGateway string `json:"transitGateway,omitempty"`
+
+	// Name of the route table of the TransitGateway the route was propagated into
+	// +optional
+	RouteTable string `json:"routeTable,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="VPC",type=string,JSONPath=`.spec.vpc.name`
+// +kubebuilder:printcolumn:name="Bound Pod",type=string,JSONPath=`.status.boundPod`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // VPCAttachment is the Schema for the vpcattachments API
 type VPCAttachment struct {
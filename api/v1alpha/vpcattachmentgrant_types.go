@@ -0,0 +1,62 @@
+package v1alpha
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VPCAttachmentGrantSpec defines the desired state of a VPCAttachmentGrant
+type VPCAttachmentGrantSpec struct {
+	// The namespaces whose VPCAttachments may reference the VPCs listed in To
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	From []VPCAttachmentGrantFrom `json:"from"`
+
+	// The VPCs in the namespace of the grant that may be referenced
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	To []VPCAttachmentGrantTo `json:"to"`
+}
+
+// VPCAttachmentGrantFrom describes the VPCAttachments a grant applies to.
+type VPCAttachmentGrantFrom struct {
+	// Namespace of the VPCAttachments
+	// +required
+	Namespace string `json:"namespace"`
+}
+
+// VPCAttachmentGrantTo describes the VPCs a grant allows to reference.
+type VPCAttachmentGrantTo struct {
+	// Name of the VPC. If empty, all VPCs in the namespace of the grant may be referenced.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VPCAttachmentGrant allows VPCAttachments in other namespaces to reference VPCs
+// in the namespace of the grant. VPCAttachments may always reference VPCs in
+// their own namespace.
+type VPCAttachmentGrant struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of a VPCAttachmentGrant
+	// +required
+	Spec VPCAttachmentGrantSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// VPCAttachmentGrantList contains a list of VPCAttachmentGrants
+type VPCAttachmentGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VPCAttachmentGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VPCAttachmentGrant{}, &VPCAttachmentGrantList{})
+}
@@ -0,0 +1,97 @@
+package v1alpha
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VPCAttachmentTemplateAnnotation lists the VPCAttachmentTemplates a Pod gets a VPCAttachment of its own from.
+// The VPCAttachments are named <pod>-<template>, so only Pods created with a name, like those of a
+// StatefulSet, may use it.
+const VPCAttachmentTemplateAnnotation = "k8s.v1alpha.galactic.datumapis.com/vpc-attachment-template"
+
+// VPCAttachmentTemplateLabel names the VPCAttachmentTemplate a VPCAttachment was stamped out from.
+const VPCAttachmentTemplateLabel = "galactic.datumapis.com/vpc-attachment-template"
+
+// VPCAttachmentPodLabel names the Pod a VPCAttachment stamped out from a VPCAttachmentTemplate belongs to.
+const VPCAttachmentPodLabel = "galactic.datumapis.com/pod"
+
+// VPCAttachmentTemplateSpec defines the desired state of a VPCAttachmentTemplate
+type VPCAttachmentTemplateSpec struct {
+	// VPC the VPCAttachments stamped out from this template belong to.
+	// +required
+	VPC corev1.ObjectReference `json:"vpc"`
+
+	// Interface defines the network interface configuration of the VPCAttachments.
+	// +required
+	Interface VPCAttachmentTemplateInterface `json:"interface"`
+
+	// Routes defines additional routing entries for the VPCAttachments.
+	// +optional
+	Routes []VPCAttachmentRoute `json:"routes,omitempty"`
+
+	// Rules defines policy routing rules for the VPCAttachments.
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	Rules []VPCAttachmentRule `json:"rules,omitempty"`
+
+	// SecurityGroups names the VPCSecurityGroups that restrict the traffic of the VPCAttachments.
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=set
+	// +optional
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+}
+
+// VPCAttachmentTemplateInterface defines the network interface configuration of the
+// VPCAttachments stamped out from a VPCAttachmentTemplate.
+type VPCAttachmentTemplateInterface struct {
+	// Name of the interface (e.g., eth0).
+	// +required
+	// +default:value="galactic0"
+	Name string `json:"name"`
+
+	// A list of IPv4 or IPv6 networks in CIDR notation within the VPC networks the addresses
+	// of the interfaces are allocated from. Pods of a StatefulSet get the address matching their
+	// ordinal unless another VPCAttachment uses it, so that it stays the same when the Pod is
+	// recreated. If empty, addresses are allocated from the VPC networks.
+	// +optional
+	AddressRanges []string `json:"addressRanges,omitempty"`
+
+	// A list of IPv4 or IPv6 gateway addresses, at most one per address family,
+	// through which the default route of the Pods is installed on this interface.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	DefaultRoute []string `json:"defaultRoute,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="VPC",type=string,JSONPath=`.spec.vpc.name`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VPCAttachmentTemplate is the Schema for the vpcattachmenttemplates API. Pods
+// listing it in their vpc-attachment-template annotation get a VPCAttachment
+// of their own stamped out from it.
+type VPCAttachmentTemplate struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of a VPCAttachmentTemplate
+	// +required
+	Spec VPCAttachmentTemplateSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// VPCAttachmentTemplateList contains a list of VPCAttachmentTemplates
+type VPCAttachmentTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VPCAttachmentTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VPCAttachmentTemplate{}, &VPCAttachmentTemplateList{})
+}
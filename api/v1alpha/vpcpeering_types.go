@@ -0,0 +1,111 @@
+package v1alpha
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// VPCPeeringConditionAccepted is true once the owners of both VPCs requested the peering.
+	VPCPeeringConditionAccepted = "Accepted"
+	// VPCPeeringReasonAccepted is the reason for an Accepted condition with a matching VPCPeering of the peer VPC.
+	VPCPeeringReasonAccepted = "Accepted"
+	// VPCPeeringReasonPendingAcceptance is the reason for an Accepted condition still waiting for the peer VPC.
+	VPCPeeringReasonPendingAcceptance = "PendingAcceptance"
+	// VPCPeeringReasonVPCNotFound is the reason for a Ready condition referencing a missing VPC.
+	VPCPeeringReasonVPCNotFound = "VPCNotFound"
+	// VPCPeeringReasonVPCNotReady is the reason for a Ready condition referencing a VPC that is not ready.
+	VPCPeeringReasonVPCNotReady = "VPCNotReady"
+	// VPCPeeringReasonNetworksOverlap is the reason for a Ready condition of VPCs with overlapping networks.
+	VPCPeeringReasonNetworksOverlap = "NetworksOverlap"
+)
+
+// VPCPeeringSpec defines the desired state of a VPCPeering
+type VPCPeeringSpec struct {
+	// Name of the VPC in the namespace of the VPCPeering to connect
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	VPC string `json:"vpc"`
+
+	// The VPC to connect to
+	// +required
+	PeerVPC VPCPeeringPeer `json:"peerVPC"`
+}
+
+// VPCPeeringPeer references the peer VPC of a VPCPeering.
+type VPCPeeringPeer struct {
+	// Name of the peer VPC
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the peer VPC, defaults to the namespace of the VPCPeering
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// VPCPeeringStatus defines the observed state of a VPCPeering
+type VPCPeeringStatus struct {
+	// Indicates whether the VPCs are connected, mirrors the Ready condition
+	// +required
+	// +default:value=false
+	Ready bool `json:"ready,omitempty"`
+
+	// A machine-readable explanation of the Ready state, mirrors the reason of the Ready condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human-readable explanation of the Ready state, mirrors the message of the Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The generation of the VPCPeering the status was last computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describing the state of the VPCPeering
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="VPC",type=string,JSONPath=`.spec.vpc`
+// +kubebuilder:printcolumn:name="Peer VPC",type=string,JSONPath=`.spec.peerVPC.name`
+// +kubebuilder:printcolumn:name="Peer Namespace",type=string,JSONPath=`.spec.peerVPC.namespace`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VPCPeering connects a VPC to a VPC in the same or another namespace. The VPCs
+// are connected once the namespace of the peer VPC holds a VPCPeering in the
+// opposite direction, so that the owners of both VPCs agree to the peering.
+type VPCPeering struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of a VPCPeering
+	// +required
+	Spec VPCPeeringSpec `json:"spec"`
+
+	// status defines the observed state of a VPCPeering
+	// +optional
+	Status VPCPeeringStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// VPCPeeringList contains a list of VPCPeerings
+type VPCPeeringList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VPCPeering `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VPCPeering{}, &VPCPeeringList{})
+}
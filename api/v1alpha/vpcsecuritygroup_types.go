@@ -0,0 +1,109 @@
+package v1alpha
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VPCSecurityGroupProtocol is the protocol a VPCSecurityGroupRule applies to.
+// +kubebuilder:validation:Enum=TCP;UDP;SCTP;ICMP;ICMPv6
+type VPCSecurityGroupProtocol string
+
+const (
+	VPCSecurityGroupProtocolTCP    VPCSecurityGroupProtocol = "TCP"
+	VPCSecurityGroupProtocolUDP    VPCSecurityGroupProtocol = "UDP"
+	VPCSecurityGroupProtocolSCTP   VPCSecurityGroupProtocol = "SCTP"
+	VPCSecurityGroupProtocolICMP   VPCSecurityGroupProtocol = "ICMP"
+	VPCSecurityGroupProtocolICMPv6 VPCSecurityGroupProtocol = "ICMPv6"
+)
+
+// VPCSecurityGroupSpec defines the desired state of a VPCSecurityGroup
+type VPCSecurityGroupSpec struct {
+	// Rules for the traffic the VPCAttachments of the VPCSecurityGroup receive
+	// +kubebuilder:validation:MaxItems=64
+	// +listType=atomic
+	// +optional
+	Ingress []VPCSecurityGroupRule `json:"ingress,omitempty"`
+
+	// Rules for the traffic the VPCAttachments of the VPCSecurityGroup send
+	// +kubebuilder:validation:MaxItems=64
+	// +listType=atomic
+	// +optional
+	Egress []VPCSecurityGroupRule `json:"egress,omitempty"`
+}
+
+// VPCSecurityGroupRule allows traffic from or to its peers matching its protocol and ports.
+type VPCSecurityGroupRule struct {
+	// The peers the traffic is allowed from or to. A rule without peers applies to all addresses.
+	// +kubebuilder:validation:MaxItems=32
+	// +listType=atomic
+	// +optional
+	Peers []VPCSecurityGroupPeer `json:"peers,omitempty"`
+
+	// The protocol of the allowed traffic. A rule without protocol applies to all protocols.
+	// +optional
+	Protocol VPCSecurityGroupProtocol `json:"protocol,omitempty"`
+
+	// The destination ports of the allowed traffic, only for the TCP, UDP and SCTP protocols.
+	// A rule without ports applies to all ports.
+	// +kubebuilder:validation:MaxItems=32
+	// +listType=atomic
+	// +optional
+	Ports []VPCSecurityGroupPort `json:"ports,omitempty"`
+}
+
+// VPCSecurityGroupPeer selects addresses by network or by VPCAttachment. Exactly one of the fields must be set.
+type VPCSecurityGroupPeer struct {
+	// An IPv4 or IPv6 network in CIDR notation
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
+
+	// Selects the VPCAttachments in the namespace of the VPCSecurityGroup by their labels. Only the
+	// addresses of VPCAttachments of the same VPC or of VPCs reachable from it are allowed.
+	// +optional
+	VPCAttachmentSelector *metav1.LabelSelector `json:"vpcAttachmentSelector,omitempty"`
+}
+
+// VPCSecurityGroupPort is a port or a range of ports.
+type VPCSecurityGroupPort struct {
+	// The port, or the first port of the range
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +required
+	Port int32 `json:"port"`
+
+	// The last port of the range, if any
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	EndPort int32 `json:"endPort,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VPCSecurityGroup restricts the traffic of the VPCAttachments referencing it. Traffic of a
+// VPCAttachment with security groups is denied in both directions unless a rule of one of its
+// security groups allows it, VPCAttachments without security groups are not restricted.
+type VPCSecurityGroup struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of a VPCSecurityGroup
+	// +required
+	Spec VPCSecurityGroupSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// VPCSecurityGroupList contains a list of VPCSecurityGroups
+type VPCSecurityGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VPCSecurityGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VPCSecurityGroup{}, &VPCSecurityGroupList{})
+}
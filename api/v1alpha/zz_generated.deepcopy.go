@@ -21,30 +21,597 @@ limitations under the License.
 package v1alpha
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentifierClaim) DeepCopyInto(out *IdentifierClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentifierClaim.
+func (in *IdentifierClaim) DeepCopy() *IdentifierClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentifierClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IdentifierClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentifierClaimList) DeepCopyInto(out *IdentifierClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IdentifierClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentifierClaimList.
+func (in *IdentifierClaimList) DeepCopy() *IdentifierClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentifierClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IdentifierClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentifierClaimSpec) DeepCopyInto(out *IdentifierClaimSpec) {
+	*out = *in
+	out.Claimant = in.Claimant
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentifierClaimSpec.
+func (in *IdentifierClaimSpec) DeepCopy() *IdentifierClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentifierClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitGateway) DeepCopyInto(out *TransitGateway) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitGateway.
+func (in *TransitGateway) DeepCopy() *TransitGateway {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitGateway)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TransitGateway) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitGatewayList) DeepCopyInto(out *TransitGatewayList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TransitGateway, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitGatewayList.
+func (in *TransitGatewayList) DeepCopy() *TransitGatewayList {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitGatewayList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TransitGatewayList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitGatewayRouteTable) DeepCopyInto(out *TransitGatewayRouteTable) {
+	*out = *in
+	if in.PropagateFrom != nil {
+		in, out := &in.PropagateFrom, &out.PropagateFrom
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitGatewayRouteTable.
+func (in *TransitGatewayRouteTable) DeepCopy() *TransitGatewayRouteTable {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitGatewayRouteTable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitGatewaySpec) DeepCopyInto(out *TransitGatewaySpec) {
+	*out = *in
+	if in.RouteTables != nil {
+		in, out := &in.RouteTables, &out.RouteTables
+		*out = make([]TransitGatewayRouteTable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitGatewaySpec.
+func (in *TransitGatewaySpec) DeepCopy() *TransitGatewaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitGatewaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitGatewayStatus) DeepCopyInto(out *TransitGatewayStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitGatewayStatus.
+func (in *TransitGatewayStatus) DeepCopy() *TransitGatewayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitGatewayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VPC) DeepCopyInto(out *VPC) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPC.
+func (in *VPC) DeepCopy() *VPC {
+	if in == nil {
+		return nil
+	}
+	out := new(VPC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPC) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachment) DeepCopyInto(out *VPCAttachment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachment.
+func (in *VPCAttachment) DeepCopy() *VPCAttachment {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCAttachment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentEffectiveRoute) DeepCopyInto(out *VPCAttachmentEffectiveRoute) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentEffectiveRoute.
+func (in *VPCAttachmentEffectiveRoute) DeepCopy() *VPCAttachmentEffectiveRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentEffectiveRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentGrant) DeepCopyInto(out *VPCAttachmentGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentGrant.
+func (in *VPCAttachmentGrant) DeepCopy() *VPCAttachmentGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCAttachmentGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentGrantFrom) DeepCopyInto(out *VPCAttachmentGrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentGrantFrom.
+func (in *VPCAttachmentGrantFrom) DeepCopy() *VPCAttachmentGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentGrantList) DeepCopyInto(out *VPCAttachmentGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VPCAttachmentGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentGrantList.
+func (in *VPCAttachmentGrantList) DeepCopy() *VPCAttachmentGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCAttachmentGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentGrantSpec) DeepCopyInto(out *VPCAttachmentGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]VPCAttachmentGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]VPCAttachmentGrantTo, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentGrantSpec.
+func (in *VPCAttachmentGrantSpec) DeepCopy() *VPCAttachmentGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentGrantTo) DeepCopyInto(out *VPCAttachmentGrantTo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentGrantTo.
+func (in *VPCAttachmentGrantTo) DeepCopy() *VPCAttachmentGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentInterface) DeepCopyInto(out *VPCAttachmentInterface) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AddressRanges != nil {
+		in, out := &in.AddressRanges, &out.AddressRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AddressIndex != nil {
+		in, out := &in.AddressIndex, &out.AddressIndex
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DefaultRoute != nil {
+		in, out := &in.DefaultRoute, &out.DefaultRoute
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentInterface.
+func (in *VPCAttachmentInterface) DeepCopy() *VPCAttachmentInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentList) DeepCopyInto(out *VPCAttachmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VPCAttachment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentList.
+func (in *VPCAttachmentList) DeepCopy() *VPCAttachmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCAttachmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentNextHop) DeepCopyInto(out *VPCAttachmentNextHop) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentNextHop.
+func (in *VPCAttachmentNextHop) DeepCopy() *VPCAttachmentNextHop {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentNextHop)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentRoute) DeepCopyInto(out *VPCAttachmentRoute) {
+	*out = *in
+	if in.NextHops != nil {
+		in, out := &in.NextHops, &out.NextHops
+		*out = make([]VPCAttachmentNextHop, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentRoute.
+func (in *VPCAttachmentRoute) DeepCopy() *VPCAttachmentRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentRule) DeepCopyInto(out *VPCAttachmentRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentRule.
+func (in *VPCAttachmentRule) DeepCopy() *VPCAttachmentRule {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentSpec) DeepCopyInto(out *VPCAttachmentSpec) {
+	*out = *in
+	out.VPC = in.VPC
+	in.Interface.DeepCopyInto(&out.Interface)
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]VPCAttachmentRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]VPCAttachmentRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecurityGroups != nil {
+		in, out := &in.SecurityGroups, &out.SecurityGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentSpec.
+func (in *VPCAttachmentSpec) DeepCopy() *VPCAttachmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentStatus) DeepCopyInto(out *VPCAttachmentStatus) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EffectiveRoutes != nil {
+		in, out := &in.EffectiveRoutes, &out.EffectiveRoutes
+		*out = make([]VPCAttachmentEffectiveRoute, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentStatus.
+func (in *VPCAttachmentStatus) DeepCopy() *VPCAttachmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentTemplate) DeepCopyInto(out *VPCAttachmentTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPC.
-func (in *VPC) DeepCopy() *VPC {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentTemplate.
+func (in *VPCAttachmentTemplate) DeepCopy() *VPCAttachmentTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(VPC)
+	out := new(VPCAttachmentTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VPC) DeepCopyObject() runtime.Object {
+func (in *VPCAttachmentTemplate) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -52,26 +619,56 @@ func (in *VPC) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCAttachment) DeepCopyInto(out *VPCAttachment) {
+func (in *VPCAttachmentTemplateInterface) DeepCopyInto(out *VPCAttachmentTemplateInterface) {
+	*out = *in
+	if in.AddressRanges != nil {
+		in, out := &in.AddressRanges, &out.AddressRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultRoute != nil {
+		in, out := &in.DefaultRoute, &out.DefaultRoute
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentTemplateInterface.
+func (in *VPCAttachmentTemplateInterface) DeepCopy() *VPCAttachmentTemplateInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCAttachmentTemplateInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCAttachmentTemplateList) DeepCopyInto(out *VPCAttachmentTemplateList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VPCAttachmentTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachment.
-func (in *VPCAttachment) DeepCopy() *VPCAttachment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentTemplateList.
+func (in *VPCAttachmentTemplateList) DeepCopy() *VPCAttachmentTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCAttachment)
+	out := new(VPCAttachmentTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VPCAttachment) DeepCopyObject() runtime.Object {
+func (in *VPCAttachmentTemplateList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -79,51 +676,65 @@ func (in *VPCAttachment) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCAttachmentInterface) DeepCopyInto(out *VPCAttachmentInterface) {
+func (in *VPCAttachmentTemplateSpec) DeepCopyInto(out *VPCAttachmentTemplateSpec) {
 	*out = *in
-	if in.Addresses != nil {
-		in, out := &in.Addresses, &out.Addresses
+	out.VPC = in.VPC
+	in.Interface.DeepCopyInto(&out.Interface)
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]VPCAttachmentRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]VPCAttachmentRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecurityGroups != nil {
+		in, out := &in.SecurityGroups, &out.SecurityGroups
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentInterface.
-func (in *VPCAttachmentInterface) DeepCopy() *VPCAttachmentInterface {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentTemplateSpec.
+func (in *VPCAttachmentTemplateSpec) DeepCopy() *VPCAttachmentTemplateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCAttachmentInterface)
+	out := new(VPCAttachmentTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCAttachmentList) DeepCopyInto(out *VPCAttachmentList) {
+func (in *VPCList) DeepCopyInto(out *VPCList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VPCAttachment, len(*in))
+		*out = make([]VPC, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentList.
-func (in *VPCAttachmentList) DeepCopy() *VPCAttachmentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCList.
+func (in *VPCList) DeepCopy() *VPCList {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCAttachmentList)
+	out := new(VPCList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VPCAttachmentList) DeepCopyObject() runtime.Object {
+func (in *VPCList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -131,89 +742,266 @@ func (in *VPCAttachmentList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCAttachmentRoute) DeepCopyInto(out *VPCAttachmentRoute) {
+func (in *VPCPeering) DeepCopyInto(out *VPCPeering) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentRoute.
-func (in *VPCAttachmentRoute) DeepCopy() *VPCAttachmentRoute {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCPeering.
+func (in *VPCPeering) DeepCopy() *VPCPeering {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCAttachmentRoute)
+	out := new(VPCPeering)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCPeering) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCAttachmentSpec) DeepCopyInto(out *VPCAttachmentSpec) {
+func (in *VPCPeeringList) DeepCopyInto(out *VPCPeeringList) {
 	*out = *in
-	out.VPC = in.VPC
-	in.Interface.DeepCopyInto(&out.Interface)
-	if in.Routes != nil {
-		in, out := &in.Routes, &out.Routes
-		*out = make([]VPCAttachmentRoute, len(*in))
-		copy(*out, *in)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VPCPeering, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentSpec.
-func (in *VPCAttachmentSpec) DeepCopy() *VPCAttachmentSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCPeeringList.
+func (in *VPCPeeringList) DeepCopy() *VPCPeeringList {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCAttachmentSpec)
+	out := new(VPCPeeringList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCPeeringList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCAttachmentStatus) DeepCopyInto(out *VPCAttachmentStatus) {
+func (in *VPCPeeringPeer) DeepCopyInto(out *VPCPeeringPeer) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCAttachmentStatus.
-func (in *VPCAttachmentStatus) DeepCopy() *VPCAttachmentStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCPeeringPeer.
+func (in *VPCPeeringPeer) DeepCopy() *VPCPeeringPeer {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCAttachmentStatus)
+	out := new(VPCPeeringPeer)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VPCList) DeepCopyInto(out *VPCList) {
+func (in *VPCPeeringSpec) DeepCopyInto(out *VPCPeeringSpec) {
+	*out = *in
+	out.PeerVPC = in.PeerVPC
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCPeeringSpec.
+func (in *VPCPeeringSpec) DeepCopy() *VPCPeeringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCPeeringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCPeeringStatus) DeepCopyInto(out *VPCPeeringStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCPeeringStatus.
+func (in *VPCPeeringStatus) DeepCopy() *VPCPeeringStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCPeeringStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCSecurityGroup) DeepCopyInto(out *VPCSecurityGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSecurityGroup.
+func (in *VPCSecurityGroup) DeepCopy() *VPCSecurityGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCSecurityGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VPCSecurityGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCSecurityGroupList) DeepCopyInto(out *VPCSecurityGroupList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VPC, len(*in))
+		*out = make([]VPCSecurityGroup, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCList.
-func (in *VPCList) DeepCopy() *VPCList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSecurityGroupList.
+func (in *VPCSecurityGroupList) DeepCopy() *VPCSecurityGroupList {
 	if in == nil {
 		return nil
 	}
-	out := new(VPCList)
+	out := new(VPCSecurityGroupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VPCList) DeepCopyObject() runtime.Object {
+func (in *VPCSecurityGroupList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCSecurityGroupPeer) DeepCopyInto(out *VPCSecurityGroupPeer) {
+	*out = *in
+	if in.VPCAttachmentSelector != nil {
+		in, out := &in.VPCAttachmentSelector, &out.VPCAttachmentSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSecurityGroupPeer.
+func (in *VPCSecurityGroupPeer) DeepCopy() *VPCSecurityGroupPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCSecurityGroupPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCSecurityGroupPort) DeepCopyInto(out *VPCSecurityGroupPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSecurityGroupPort.
+func (in *VPCSecurityGroupPort) DeepCopy() *VPCSecurityGroupPort {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCSecurityGroupPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCSecurityGroupRule) DeepCopyInto(out *VPCSecurityGroupRule) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]VPCSecurityGroupPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]VPCSecurityGroupPort, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSecurityGroupRule.
+func (in *VPCSecurityGroupRule) DeepCopy() *VPCSecurityGroupRule {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCSecurityGroupRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCSecurityGroupSpec) DeepCopyInto(out *VPCSecurityGroupSpec) {
+	*out = *in
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = make([]VPCSecurityGroupRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]VPCSecurityGroupRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSecurityGroupSpec.
+func (in *VPCSecurityGroupSpec) DeepCopy() *VPCSecurityGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCSecurityGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VPCSpec) DeepCopyInto(out *VPCSpec) {
 	*out = *in
@@ -222,6 +1010,11 @@ func (in *VPCSpec) DeepCopyInto(out *VPCSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TransitGateway != nil {
+		in, out := &in.TransitGateway, &out.TransitGateway
+		*out = new(VPCTransitGatewayAssociation)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCSpec.
@@ -237,6 +1030,13 @@ func (in *VPCSpec) DeepCopy() *VPCSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VPCStatus) DeepCopyInto(out *VPCStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCStatus.
@@ -248,3 +1048,18 @@ func (in *VPCStatus) DeepCopy() *VPCStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCTransitGatewayAssociation) DeepCopyInto(out *VPCTransitGatewayAssociation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCTransitGatewayAssociation.
+func (in *VPCTransitGatewayAssociation) DeepCopy() *VPCTransitGatewayAssociation {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCTransitGatewayAssociation)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"maps"
 	"os"
 	"path/filepath"
 
@@ -30,6 +32,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -40,8 +43,10 @@ import (
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
 	"github.com/datum-cloud/galactic-operator/internal/controller"
 	webhookv1 "github.com/datum-cloud/galactic-operator/internal/webhook/v1"
+	webhookv1alpha "github.com/datum-cloud/galactic-operator/internal/webhook/v1alpha"
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
+	"github.com/datum-cloud/galactic-operator/internal/fieldindex"
 	"github.com/datum-cloud/galactic-operator/internal/identifier"
 	// +kubebuilder:scaffold:imports
 )
@@ -69,6 +74,8 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var mtu int
+	var rejectAddressConflicts bool
+	var podWebhookNamespaceSelector, podWebhookObjectSelector, podWebhookFailurePolicy string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -89,6 +96,17 @@ func main() {
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	flag.IntVar(&mtu, "mtu", 1372,
 		"The MTU to configure for CNI network interfaces.")
+	flag.BoolVar(&rejectAddressConflicts, "reject-address-conflicts", false,
+		"If set, the VPCAttachment webhook rejects addresses already in use by another VPCAttachment of the same VPC.")
+	flag.StringVar(&podWebhookNamespaceSelector, "pod-webhook-namespace-selector",
+		"kubernetes.io/metadata.name notin (kube-system)",
+		"The label selector of the namespaces whose pods are sent to the pod webhook. "+
+			"Leave empty to keep the selector of the deployed webhook configuration.")
+	flag.StringVar(&podWebhookObjectSelector, "pod-webhook-object-selector", "",
+		"The label selector of the pods sent to the pod webhook. "+
+			"Leave empty to keep the selector of the deployed webhook configuration.")
+	flag.StringVar(&podWebhookFailurePolicy, "pod-webhook-failure-policy", "Fail",
+		"The failure policy of the pod webhook, Fail or Ignore to admit pods while the operator is unavailable.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -186,8 +204,26 @@ func main() {
 		})
 	}
 
+	// Only the Pods attached to VPCAttachments are cached. The operator may
+	// only read the webhook configurations registering the pod webhook, so
+	// only those are cached either.
+	cacheOptions := cache.Options{ByObject: controller.PodCacheByObject()}
+	var podWebhookRegistration *webhookv1.PodWebhookRegistration
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		var err error
+		podWebhookRegistration, err = webhookv1.ParsePodWebhookRegistration(
+			podWebhookNamespaceSelector, podWebhookObjectSelector, podWebhookFailurePolicy)
+		if err != nil {
+			setupLog.Error(err, "invalid pod webhook registration")
+			os.Exit(1)
+		}
+		maps.Copy(cacheOptions.ByObject, podWebhookRegistration.CacheByObject())
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOptions,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -210,6 +246,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := fieldindex.Setup(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
 	if err := (&controller.VPCReconciler{
 		Client:     mgr.GetClient(),
 		Scheme:     mgr.GetScheme(),
@@ -227,12 +267,57 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "VPCAttachment")
 		os.Exit(1)
 	}
+	if err := (&controller.VPCPeeringReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VPCPeering")
+		os.Exit(1)
+	}
+	if err := (&controller.TransitGatewayReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TransitGateway")
+		os.Exit(1)
+	}
+	if err := (&controller.PodReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Pod")
+		os.Exit(1)
+	}
 	// nolint:goconst
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
 		if err := webhookv1.SetupPodWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
 			os.Exit(1)
 		}
+		if err := webhookv1.SetupPodWebhookRegistrationWithManager(mgr, podWebhookRegistration); err != nil {
+			setupLog.Error(err, "unable to register webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha.SetupVPCWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VPC")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha.SetupVPCAttachmentWebhookWithManager(mgr, rejectAddressConflicts); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VPCAttachment")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha.SetupVPCPeeringWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VPCPeering")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha.SetupTransitGatewayWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "TransitGateway")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha.SetupVPCSecurityGroupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VPCSecurityGroup")
+			os.Exit(1)
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
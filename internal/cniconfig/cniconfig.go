@@ -3,6 +3,9 @@ package cniconfig
 import (
 	"fmt"
 	"net"
+	"slices"
+	"strings"
+	"unicode"
 
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
 
@@ -22,18 +25,226 @@ type PluginConfGalactic struct {
 	VPCAttachment string            `json:"vpcattachment"`
 	MTU           int               `json:"mtu,omitempty"`
 	Terminations  []cni.Termination `json:"terminations,omitempty"`
+	Rules         []Rule            `json:"rules,omitempty"`
+	Peers         []Peer            `json:"peers,omitempty"`
+	Firewall      *Firewall         `json:"firewall,omitempty"`
+	Routes        []Route           `json:"routes,omitempty"`
 	IPAM          cni.IPAM          `json:"ipam,omitempty"`
 }
 
-func CNIConfigForVPCAttachment(vpc galacticv1alpha.VPC, vpcAttachment galacticv1alpha.VPCAttachment, mtu int) (NetConfList, error) {
+// Firewall restricts the traffic of the interface to the traffic allowed by
+// its rules. Without a firewall the traffic is not restricted.
+type Firewall struct {
+	Ingress []FirewallRule `json:"ingress"`
+	Egress  []FirewallRule `json:"egress"`
+}
+
+// FirewallRule allows traffic from or to Networks using Protocol and Ports.
+// Empty fields match all networks, protocols or ports.
+type FirewallRule struct {
+	Networks []string `json:"networks,omitempty"`
+	Protocol string   `json:"protocol,omitempty"`
+	Ports    []string `json:"ports,omitempty"`
+}
+
+// Peer is a VPC connected by a VPCPeering or reachable through a
+// TransitGateway along with its networks
+type Peer struct {
+	VPC      string   `json:"vpc"`
+	Networks []string `json:"networks"`
+}
+
+// Rule is a policy routing rule looking up Table for traffic from Src to Dst
+type Rule struct {
+	Src      string `json:"src,omitempty"`
+	Dst      string `json:"dst,omitempty"`
+	Priority int32  `json:"priority,omitempty"`
+	Table    int32  `json:"table"`
+}
+
+// Route is a route through GW, through all NextHops or, if neither is set,
+// directly through the interface Dev. The static IPAM plugin only sets up
+// routes through GW, all other routes are set up by the galactic plugin
+// itself from its own routes section.
+type Route struct {
+	Dst      string    `json:"dst"`
+	GW       string    `json:"gw,omitempty"`
+	Dev      string    `json:"dev,omitempty"`
+	NextHops []NextHop `json:"nextHops,omitempty"`
+	Metric   int32     `json:"metric,omitempty"`
+	Table    int32     `json:"table,omitempty"`
+	Src      string    `json:"src,omitempty"`
+}
+
+type NextHop struct {
+	GW     string `json:"gw"`
+	Weight int32  `json:"weight,omitempty"`
+}
+
+// MaxInterfaceNameLength is the longest network interface name accepted by
+// the Linux kernel (IFNAMSIZ minus the terminating NUL byte).
+const MaxInterfaceNameLength = 15
+
+// ParseNetwork parses a network in IPv4 or IPv6 CIDR notation. Unlike
+// net.ParseCIDR it rejects networks with host bits set and IPv4 networks
+// written in IPv4-mapped IPv6 notation.
+func ParseNetwork(network string) (*net.IPNet, error) {
+	ip, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil, err
+	}
+	if isIPv4Mapped(ipNet) {
+		return nil, fmt.Errorf("network %q uses IPv4-mapped IPv6 notation", network)
+	}
+	if !ip.Equal(ipNet.IP) {
+		return nil, fmt.Errorf("network %q has host bits set (expected %q)", network, ipNet.String())
+	}
+	return ipNet, nil
+}
+
+// ParseAddress parses an interface address in CIDR notation, returning the
+// address itself and the network it is on.
+func ParseAddress(address string) (net.IP, *net.IPNet, error) {
+	ip, ipNet, err := net.ParseCIDR(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isIPv4Mapped(ipNet) {
+		return nil, nil, fmt.Errorf("address %q uses IPv4-mapped IPv6 notation", address)
+	}
+	return ip, ipNet, nil
+}
+
+// ParseRoute parses the destination and next hops of a route. The returned next
+// hops are empty for on-link routes and follow the order of NextHops for
+// multipath routes.
+func ParseRoute(route galacticv1alpha.VPCAttachmentRoute) (*net.IPNet, []net.IP, error) {
+	_, destination, err := net.ParseCIDR(route.Destination)
+	if err != nil {
+		return nil, nil, err
+	}
+	if route.Via != "" && len(route.NextHops) > 0 {
+		return nil, nil, fmt.Errorf("route to %q sets both via and nextHops", route.Destination)
+	}
+
+	vias := make([]string, 0, len(route.NextHops)+1)
+	if route.Via != "" {
+		vias = append(vias, route.Via)
+	}
+	for _, nextHop := range route.NextHops {
+		vias = append(vias, nextHop.Via)
+	}
+
+	nextHops := make([]net.IP, 0, len(vias))
+	for _, via := range vias {
+		nextHop := net.ParseIP(via)
+		if nextHop == nil {
+			return nil, nil, fmt.Errorf("failed to parse route via %q", via)
+		}
+		if (destination.IP.To4() == nil) != (nextHop.To4() == nil) {
+			return nil, nil, fmt.Errorf("route via %q is not in the same address family as destination %q", via, route.Destination)
+		}
+		if slices.ContainsFunc(nextHops, nextHop.Equal) {
+			return nil, nil, fmt.Errorf("route to %q uses next hop %q twice", route.Destination, via)
+		}
+		nextHops = append(nextHops, nextHop)
+	}
+
+	if route.Source != "" {
+		source := net.ParseIP(route.Source)
+		if source == nil {
+			return nil, nil, fmt.Errorf("failed to parse route source %q", route.Source)
+		}
+		if (destination.IP.To4() == nil) != (source.To4() == nil) {
+			return nil, nil, fmt.Errorf("route source %q is not in the same address family as destination %q", route.Source, route.Destination)
+		}
+	}
+	return destination, nextHops, nil
+}
+
+// ParseRule parses the networks selected by a policy routing rule. The
+// returned networks are nil when the rule does not select on them.
+func ParseRule(rule galacticv1alpha.VPCAttachmentRule) (*net.IPNet, *net.IPNet, error) {
+	if rule.From == "" && rule.To == "" {
+		return nil, nil, fmt.Errorf("rule must select traffic from or to a network")
+	}
+	var from, to *net.IPNet
+	var err error
+	if rule.From != "" {
+		if _, from, err = net.ParseCIDR(rule.From); err != nil {
+			return nil, nil, err
+		}
+	}
+	if rule.To != "" {
+		if _, to, err = net.ParseCIDR(rule.To); err != nil {
+			return nil, nil, err
+		}
+	}
+	if from != nil && to != nil && (from.IP.To4() == nil) != (to.IP.To4() == nil) {
+		return nil, nil, fmt.Errorf("rule from %q is not in the same address family as to %q", rule.From, rule.To)
+	}
+	return from, to, nil
+}
+
+// NetworksOverlap reports whether two networks share at least one address.
+func NetworksOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// NetworkContains reports whether inner is entirely contained in outer.
+func NetworkContains(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// ValidateInterfaceName checks that name is usable as a Linux network
+// interface name.
+func ValidateInterfaceName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("interface name must not be empty")
+	case len(name) > MaxInterfaceNameLength:
+		return fmt.Errorf("interface name %q is longer than %d characters", name, MaxInterfaceNameLength)
+	case name == "." || name == "..":
+		return fmt.Errorf("interface name %q is reserved", name)
+	case strings.ContainsAny(name, "/:") || strings.IndexFunc(name, unicode.IsSpace) >= 0:
+		return fmt.Errorf("interface name %q must not contain '/', ':' or whitespace", name)
+	}
+	return nil
+}
+
+func isIPv4Mapped(ipNet *net.IPNet) bool {
+	return len(ipNet.Mask) == net.IPv6len && ipNet.IP.To4() != nil
+}
+
+// InterfaceAddresses returns the addresses of the VPCAttachment interface:
+// the addresses from the spec if any are given, otherwise the addresses
+// allocated by the controller.
+func InterfaceAddresses(vpcAttachment galacticv1alpha.VPCAttachment) []string {
+	if len(vpcAttachment.Spec.Interface.Addresses) > 0 {
+		return vpcAttachment.Spec.Interface.Addresses
+	}
+	return vpcAttachment.Status.Addresses
+}
+
+// CNIConfigForVPCAttachment renders the CNI configuration of the VPCAttachment
+// of vpc. The networks of the peers, the VPCs connected to vpc by a VPCPeering
+// or reachable through its TransitGateway, are reached on-link through the
+// interface. The firewall, if any, restricts the traffic of the interface.
+func CNIConfigForVPCAttachment(vpc galacticv1alpha.VPC, vpcAttachment galacticv1alpha.VPCAttachment, peers []galacticv1alpha.VPC, firewall *Firewall, mtu int) (NetConfList, error) {
 	terminations := make([]cni.Termination, 0, 10)
 	addresses := make([]cni.Address, 0, 10)
-	routes := make([]cni.Route, 0, 10)
+	routes := make([]Route, 0, 10)
 
 	netAddresses := make([]net.IP, 0, 10) // to check if a route is local
 
-	for _, address := range vpcAttachment.Spec.Interface.Addresses {
-		netAddress, network, err := net.ParseCIDR(address)
+	interfaceAddresses := InterfaceAddresses(vpcAttachment)
+	if len(interfaceAddresses) == 0 {
+		return NetConfList{}, fmt.Errorf("no addresses assigned to VPCAttachment %s/%s", vpcAttachment.Namespace, vpcAttachment.Name)
+	}
+	for _, address := range interfaceAddresses {
+		netAddress, network, err := ParseAddress(address)
 		if err != nil {
 			return NetConfList{}, err
 		}
@@ -41,32 +252,101 @@ func CNIConfigForVPCAttachment(vpc galacticv1alpha.VPC, vpcAttachment galacticv1
 		addresses = append(addresses, cni.Address{Address: address})
 		terminations = append(terminations, cni.Termination{Network: network.String()})
 	}
+	local := func(via net.IP) bool {
+		return slices.ContainsFunc(netAddresses, via.Equal)
+	}
 
 	for _, route := range vpcAttachment.Spec.Routes {
-		_, network, err := net.ParseCIDR(route.Destination)
+		network, vias, err := ParseRoute(route)
 		if err != nil {
 			return NetConfList{}, err
 		}
 
-		if route.Via != "" {
-			via := net.ParseIP(route.Via)
-			if via == nil {
-				return NetConfList{}, fmt.Errorf("failed to parse route via %q", route.Via)
+		var source string
+		if route.Source != "" {
+			ip := net.ParseIP(route.Source)
+			if !local(ip) {
+				return NetConfList{}, fmt.Errorf("source %s of the route to %s is not an address of the interface", route.Source, network)
 			}
+			source = ip.String()
+		}
+		rendered := Route{Dst: network.String(), Metric: route.Metric, Table: route.Table, Src: source}
 
-			local := false
-			for _, netAddress := range netAddresses {
-				if via.Equal(netAddress) {
-					local = true
-					break
+		switch {
+		case len(vias) == 0: // on-link routes go through the interface
+			rendered.Dev = vpcAttachment.Spec.Interface.Name
+			routes = append(routes, rendered)
+		case len(route.NextHops) == 0 && local(vias[0]): // local routes are terminations
+			if route.Metric != 0 || route.Table != 0 || route.Source != "" {
+				return NetConfList{}, fmt.Errorf("route to %s terminates at the VPCAttachment and takes no metric, table or source", network)
+			}
+			terminations = append(terminations, cni.Termination{Network: network.String(), Via: vias[0].String()})
+		case len(route.NextHops) == 0:
+			rendered.GW = vias[0].String()
+			routes = append(routes, rendered)
+		default:
+			nextHops := make([]NextHop, 0, len(vias))
+			for i, via := range vias {
+				if local(via) {
+					return NetConfList{}, fmt.Errorf("next hop %s of the route to %s is a local address", via, network)
+				}
+				weight := route.NextHops[i].Weight
+				if weight == 0 {
+					weight = 1
 				}
+				nextHops = append(nextHops, NextHop{GW: via.String(), Weight: weight})
+			}
+			rendered.NextHops = nextHops
+			routes = append(routes, rendered)
+		}
+	}
+
+	var rules []Rule
+	for _, rule := range vpcAttachment.Spec.Rules {
+		from, to, err := ParseRule(rule)
+		if err != nil {
+			return NetConfList{}, err
+		}
+		rendered := Rule{Priority: rule.Priority, Table: rule.Table}
+		if from != nil {
+			rendered.Src = from.String()
+		}
+		if to != nil {
+			rendered.Dst = to.String()
+		}
+		rules = append(rules, rendered)
+	}
+
+	var renderedPeers []Peer
+	for _, peer := range peers {
+		peerIdentifierBase62, err := util.HexToBase62(peer.Status.Identifier)
+		if err != nil {
+			return NetConfList{}, err
+		}
+		networks := make([]string, 0, len(peer.Spec.Networks))
+		for _, peerNetwork := range peer.Spec.Networks {
+			_, network, err := net.ParseCIDR(peerNetwork)
+			if err != nil {
+				return NetConfList{}, err
 			}
-			if local { // local routes are terminations
-				terminations = append(terminations, cni.Termination{Network: network.String(), Via: via.String()})
-			} else {
-				routes = append(routes, cni.Route{Dst: network.String(), GW: via.String()})
+			networks = append(networks, network.String())
+			// Only networks of an address family the interface has are reachable
+			if slices.ContainsFunc(netAddresses, func(ip net.IP) bool { return (ip.To4() == nil) == (network.IP.To4() == nil) }) {
+				routes = append(routes, Route{Dst: network.String(), Dev: vpcAttachment.Spec.Interface.Name})
 			}
 		}
+		renderedPeers = append(renderedPeers, Peer{VPC: peerIdentifierBase62, Networks: networks})
+	}
+
+	// The static IPAM plugin only takes the destination and gateway of a route
+	var ipamRoutes []cni.Route
+	var pluginRoutes []Route
+	for _, route := range routes {
+		if route.GW != "" && route.Metric == 0 && route.Table == 0 && route.Src == "" {
+			ipamRoutes = append(ipamRoutes, cni.Route{Dst: route.Dst, GW: route.GW})
+		} else {
+			pluginRoutes = append(pluginRoutes, route)
+		}
 	}
 
 	vpcIdentifierBase62, err := util.HexToBase62(vpc.Status.Identifier)
@@ -87,10 +367,14 @@ func CNIConfigForVPCAttachment(vpc galacticv1alpha.VPC, vpcAttachment galacticv1
 				VPCAttachment: vpcAttachmentIdentifierBase62,
 				MTU:           mtu,
 				Terminations:  terminations,
+				Rules:         rules,
+				Peers:         renderedPeers,
+				Firewall:      firewall,
+				Routes:        pluginRoutes,
 				IPAM: cni.IPAM{
 					Type:      "static",
 					Addresses: addresses,
-					Routes:    routes,
+					Routes:    ipamRoutes,
 				},
 			},
 		},
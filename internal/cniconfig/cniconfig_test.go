@@ -1,7 +1,13 @@
 package cniconfig_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -90,7 +96,7 @@ func TestCNIConfigForVPCAttachment(t *testing.T) {
 			Identifier: "ffff",
 		},
 	}
-	actual, err := cniconfig.CNIConfigForVPCAttachment(vpc, vpcAttachment, 1372)
+	actual, err := cniconfig.CNIConfigForVPCAttachment(vpc, vpcAttachment, nil, nil, 1372)
 	if err != nil {
 		t.Errorf("CNIConfigForVPCAttachment error: %+v", err)
 	}
@@ -99,3 +105,322 @@ func TestCNIConfigForVPCAttachment(t *testing.T) {
 		t.Errorf("configs not equal\nExpected: %+v\nActual: %+v", expected, actual)
 	}
 }
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestCNIConfigForVPCAttachmentGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		routes    []galacticv1alpha.VPCAttachmentRoute
+		rules     []galacticv1alpha.VPCAttachmentRule
+		peers     []galacticv1alpha.VPC
+		firewall  *cniconfig.Firewall
+		wantError bool
+	}{
+		{"on-link-routes", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24"},
+			{Destination: "2001:1::/64"},
+		}, nil, nil, nil, false},
+		{"multipath-routes", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24", NextHops: []galacticv1alpha.VPCAttachmentNextHop{
+				{Via: "10.1.1.2", Weight: 1},
+				{Via: "10.1.1.3", Weight: 3},
+			}},
+			{Destination: "2001:1::/64", NextHops: []galacticv1alpha.VPCAttachmentNextHop{
+				{Via: "2001:10:1:1::2"},
+				{Via: "2001:10:1:1::3"},
+			}},
+		}, nil, nil, nil, false},
+		{"mixed-routes", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24", Via: "10.1.1.1"},
+			{Destination: "192.168.2.0/24", Via: "10.1.1.2"},
+			{Destination: "192.168.3.0/24"},
+			{Destination: "0.0.0.0/0", NextHops: []galacticv1alpha.VPCAttachmentNextHop{
+				{Via: "10.1.1.2", Weight: 2},
+				{Via: "10.1.1.3", Weight: 1},
+			}},
+		}, nil, nil, nil, false},
+		{"policy-routing", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "0.0.0.0/0", Via: "10.1.1.254", Table: 100, Source: "10.1.1.1"},
+			{Destination: "::/0", Via: "2001:10:1:1::fe", Table: 100, Source: "2001:10:1:1::1"},
+			{Destination: "192.168.1.0/24", Via: "10.1.1.2", Metric: 10},
+			{Destination: "192.168.1.0/24", Via: "10.1.1.3", Metric: 20},
+			{Destination: "192.168.2.0/24", Metric: 5, Source: "10.1.1.1"},
+		}, []galacticv1alpha.VPCAttachmentRule{
+			{From: "10.1.1.1/32", Priority: 100, Table: 100},
+			{From: "2001:10:1:1::1/128", Priority: 100, Table: 100},
+			{To: "172.16.0.0/12", Table: 100},
+		}, nil, nil, false},
+		{"peered-vpcs", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24", Via: "10.1.1.2"},
+		}, nil, []galacticv1alpha.VPC{
+			{
+				Spec:   galacticv1alpha.VPCSpec{Networks: []string{"10.2.0.0/16", "2001:10:2::/48"}},
+				Status: galacticv1alpha.VPCStatus{Identifier: "f5b6726c782b"},
+			},
+			{
+				Spec:   galacticv1alpha.VPCSpec{Networks: []string{"10.3.0.0/16"}},
+				Status: galacticv1alpha.VPCStatus{Identifier: "f68a7a2a17d9"},
+			},
+		}, nil, false},
+		{"firewall", nil, nil, nil, &cniconfig.Firewall{
+			Ingress: []cniconfig.FirewallRule{
+				{Networks: []string{"10.1.1.2/32", "10.2.0.0/16"}, Protocol: "tcp", Ports: []string{"80", "8000-8080"}},
+				{Protocol: "icmp"},
+			},
+			Egress: []cniconfig.FirewallRule{{}},
+		}, false},
+		{"local-next-hop", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24", NextHops: []galacticv1alpha.VPCAttachmentNextHop{
+				{Via: "10.1.1.1"},
+				{Via: "10.1.1.2"},
+			}},
+		}, nil, nil, nil, true},
+		{"foreign-source", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24", Via: "10.1.1.2", Source: "10.1.1.9"},
+		}, nil, nil, nil, true},
+		{"termination-with-table", []galacticv1alpha.VPCAttachmentRoute{
+			{Destination: "192.168.1.0/24", Via: "10.1.1.1", Table: 100},
+		}, nil, nil, nil, true},
+		{"rule-without-networks", nil, []galacticv1alpha.VPCAttachmentRule{
+			{Priority: 100, Table: 100},
+		}, nil, nil, true},
+	}
+
+	vpc := galacticv1alpha.VPC{
+		Status: galacticv1alpha.VPCStatus{Identifier: "ffffffffffff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vpcAttachment := galacticv1alpha.VPCAttachment{
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.1.1.1/24", "2001:10:1:1::1/64"},
+					},
+					Routes: tt.routes,
+					Rules:  tt.rules,
+				},
+				Status: galacticv1alpha.VPCAttachmentStatus{Identifier: "ffff"},
+			}
+			config, err := cniconfig.CNIConfigForVPCAttachment(vpc, vpcAttachment, tt.peers, tt.firewall, 1372)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("CNIConfigForVPCAttachment() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+
+			actual, err := json.MarshalIndent(config, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, '\n')
+			golden := filepath.Join("testdata", tt.name+".json")
+			if *update {
+				if err := os.WriteFile(golden, actual, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("config does not match %s\nExpected: %s\nActual: %s", golden, expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		network     string
+		wantNetwork string
+		wantError   bool
+	}{
+		{"ValidIPv4", "10.1.1.0/24", "10.1.1.0/24", false},
+		{"ValidIPv6", "2001:10:1:1::/64", "2001:10:1:1::/64", false},
+		{"InvalidNotCIDR", "not-a-cidr", "", true},
+		{"InvalidMissingPrefix", "10.1.1.0", "", true},
+		{"InvalidHostBitsIPv4", "10.1.1.1/24", "", true},
+		{"InvalidHostBitsIPv6", "2001:10:1:1::1/64", "", true},
+		{"InvalidIPv4Mapped", "::ffff:10.1.1.0/120", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cniconfig.ParseNetwork(tt.network)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ParseNetwork() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if err == nil && got.String() != tt.wantNetwork {
+				t.Errorf("ParseNetwork() got = %v, want = %v", got, tt.wantNetwork)
+			}
+		})
+	}
+}
+
+func TestNetworksOverlap(t *testing.T) {
+	tests := []struct {
+		name        string
+		a           string
+		b           string
+		wantOverlap bool
+	}{
+		{"Identical", "10.1.1.0/24", "10.1.1.0/24", true},
+		{"Contained", "10.1.0.0/16", "10.1.1.0/24", true},
+		{"Containing", "10.1.1.0/24", "10.1.0.0/16", true},
+		{"Disjoint", "10.1.1.0/24", "10.1.2.0/24", false},
+		{"DifferentFamilies", "0.0.0.0/0", "::/0", false},
+		{"ContainedIPv6", "2001:10::/32", "2001:10:1:1::/64", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := cniconfig.ParseNetwork(tt.a)
+			if err != nil {
+				t.Fatalf("ParseNetwork(%q) error = %v", tt.a, err)
+			}
+			b, err := cniconfig.ParseNetwork(tt.b)
+			if err != nil {
+				t.Fatalf("ParseNetwork(%q) error = %v", tt.b, err)
+			}
+			if got := cniconfig.NetworksOverlap(a, b); got != tt.wantOverlap {
+				t.Errorf("NetworksOverlap() got = %v, want = %v", got, tt.wantOverlap)
+			}
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     string
+		wantIP      string
+		wantNetwork string
+		wantError   bool
+	}{
+		{"ValidIPv4", "10.1.1.1/24", "10.1.1.1", "10.1.1.0/24", false},
+		{"ValidIPv6", "2001:10:1:1::1/64", "2001:10:1:1::1", "2001:10:1:1::/64", false},
+		{"InvalidNotCIDR", "10.1.1.1", "", "", true},
+		{"InvalidIPv4Mapped", "::ffff:10.1.1.1/120", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, network, err := cniconfig.ParseAddress(tt.address)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ParseAddress() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if err == nil && (ip.String() != tt.wantIP || network.String() != tt.wantNetwork) {
+				t.Errorf("ParseAddress() got = %v %v, want = %v %v", ip, network, tt.wantIP, tt.wantNetwork)
+			}
+		})
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	tests := []struct {
+		name            string
+		route           galacticv1alpha.VPCAttachmentRoute
+		wantDestination string
+		wantVias        string
+		wantError       bool
+	}{
+		{"ValidIPv4", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1.1"}, "192.168.1.0/24", "10.1.1.1", false},
+		{"ValidIPv6", galacticv1alpha.VPCAttachmentRoute{Destination: "2001:1::/64", Via: "2001:10:1:1::1"}, "2001:1::/64", "2001:10:1:1::1", false},
+		{"ValidWithoutVia", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24"}, "192.168.1.0/24", "", false},
+		{"ValidHostBitsSet", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.1/24", Via: "10.1.1.1"}, "192.168.1.0/24", "10.1.1.1", false},
+		{"ValidNextHops", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", NextHops: []galacticv1alpha.VPCAttachmentNextHop{
+			{Via: "10.1.1.2", Weight: 1}, {Via: "10.1.1.3", Weight: 2},
+		}}, "192.168.1.0/24", "10.1.1.2,10.1.1.3", false},
+		{"InvalidDestination", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0", Via: "10.1.1.1"}, "", "", true},
+		{"InvalidVia", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1"}, "", "", true},
+		{"InvalidMixedFamilies", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "2001:10:1:1::1"}, "", "", true},
+		{"InvalidSource", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1.1", Source: "10.1.1"}, "", "", true},
+		{"InvalidSourceFamily", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1.1", Source: "2001:10:1:1::1"}, "", "", true},
+		{"InvalidViaAndNextHops", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1.1",
+			NextHops: []galacticv1alpha.VPCAttachmentNextHop{{Via: "10.1.1.2"}}}, "", "", true},
+		{"InvalidNextHop", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24",
+			NextHops: []galacticv1alpha.VPCAttachmentNextHop{{Via: "10.1.1.2"}, {Via: "2001:10:1:1::1"}}}, "", "", true},
+		{"InvalidDuplicateNextHop", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24",
+			NextHops: []galacticv1alpha.VPCAttachmentNextHop{{Via: "10.1.1.2"}, {Via: "10.1.1.2"}}}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination, vias, err := cniconfig.ParseRoute(tt.route)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ParseRoute() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+			gotVias := make([]string, 0, len(vias))
+			for _, via := range vias {
+				gotVias = append(gotVias, via.String())
+			}
+			if destination.String() != tt.wantDestination || strings.Join(gotVias, ",") != tt.wantVias {
+				t.Errorf("ParseRoute() got = %v %v, want = %v %v", destination, vias, tt.wantDestination, tt.wantVias)
+			}
+		})
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      galacticv1alpha.VPCAttachmentRule
+		wantFrom  string
+		wantTo    string
+		wantError bool
+	}{
+		{"ValidFrom", galacticv1alpha.VPCAttachmentRule{From: "10.1.1.1/32", Table: 100}, "10.1.1.1/32", "<nil>", false},
+		{"ValidTo", galacticv1alpha.VPCAttachmentRule{To: "2001:1::/64", Table: 100}, "<nil>", "2001:1::/64", false},
+		{"ValidFromTo", galacticv1alpha.VPCAttachmentRule{From: "10.1.1.0/24", To: "192.168.1.0/24", Table: 100}, "10.1.1.0/24", "192.168.1.0/24", false},
+		{"InvalidEmpty", galacticv1alpha.VPCAttachmentRule{Table: 100}, "", "", true},
+		{"InvalidFrom", galacticv1alpha.VPCAttachmentRule{From: "10.1.1.1", Table: 100}, "", "", true},
+		{"InvalidMixedFamilies", galacticv1alpha.VPCAttachmentRule{From: "10.1.1.0/24", To: "2001:1::/64", Table: 100}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := cniconfig.ParseRule(tt.rule)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ParseRule() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if err == nil && (from.String() != tt.wantFrom || to.String() != tt.wantTo) {
+				t.Errorf("ParseRule() got = %v %v, want = %v %v", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestValidateInterfaceName(t *testing.T) {
+	tests := []struct {
+		name          string
+		interfaceName string
+		wantError     bool
+	}{
+		{"Valid", "galactic0", false},
+		{"ValidMaxLength", "abcdefghijklmno", false},
+		{"InvalidEmpty", "", true},
+		{"InvalidTooLong", "abcdefghijklmnop", true},
+		{"InvalidDot", ".", true},
+		{"InvalidDotDot", "..", true},
+		{"InvalidSlash", "net/0", true},
+		{"InvalidColon", "net:0", true},
+		{"InvalidWhitespace", "net 0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cniconfig.ValidateInterfaceName(tt.interfaceName)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ValidateInterfaceName() error = %v, wantError = %v", err, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+// setCondition sets a condition observed at the given generation and reports
+// whether the conditions changed.
+func setCondition(conditions *[]metav1.Condition, generation int64, conditionType string, status metav1.ConditionStatus, reason, message string) bool {
+	return meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setVPCReady sets the Ready condition of the VPC and mirrors it into the
+// plain status fields. It reports whether the status changed.
+func setVPCReady(vpc *galacticv1alpha.VPC, status metav1.ConditionStatus, reason, message string) bool {
+	changed := setCondition(&vpc.Status.Conditions, vpc.Generation, galacticv1alpha.ConditionReady, status, reason, message)
+	return mirrorReady(&vpc.Status.Ready, &vpc.Status.Reason, &vpc.Status.Message, &vpc.Status.ObservedGeneration,
+		vpc.Generation, status, reason, message) || changed
+}
+
+// setVPCAttachmentReady sets the Ready condition of the VPCAttachment and
+// mirrors it into the plain status fields. It reports whether the status
+// changed.
+func setVPCAttachmentReady(vpcAttachment *galacticv1alpha.VPCAttachment, status metav1.ConditionStatus, reason, message string) bool {
+	changed := setCondition(&vpcAttachment.Status.Conditions, vpcAttachment.Generation, galacticv1alpha.ConditionReady, status, reason, message)
+	return mirrorReady(&vpcAttachment.Status.Ready, &vpcAttachment.Status.Reason, &vpcAttachment.Status.Message, &vpcAttachment.Status.ObservedGeneration,
+		vpcAttachment.Generation, status, reason, message) || changed
+}
+
+// setVPCAttachmentNotReady marks the VPCAttachment as not ready and records
+// the reason on the condition type causing it, unless conditionType is empty.
+func setVPCAttachmentNotReady(vpcAttachment *galacticv1alpha.VPCAttachment, conditionType, reason, message string) {
+	if conditionType != "" {
+		status := metav1.ConditionFalse
+		if conditionType == galacticv1alpha.VPCAttachmentConditionConflict {
+			status = metav1.ConditionTrue
+		}
+		setCondition(&vpcAttachment.Status.Conditions, vpcAttachment.Generation, conditionType, status, reason, message)
+	}
+	setVPCAttachmentReady(vpcAttachment, metav1.ConditionFalse, reason, message)
+}
+
+// setVPCPeeringReady sets the Ready condition of the VPCPeering and mirrors it
+// into the plain status fields. It reports whether the status changed.
+func setVPCPeeringReady(vpcPeering *galacticv1alpha.VPCPeering, status metav1.ConditionStatus, reason, message string) bool {
+	changed := setCondition(&vpcPeering.Status.Conditions, vpcPeering.Generation, galacticv1alpha.ConditionReady, status, reason, message)
+	return mirrorReady(&vpcPeering.Status.Ready, &vpcPeering.Status.Reason, &vpcPeering.Status.Message, &vpcPeering.Status.ObservedGeneration,
+		vpcPeering.Generation, status, reason, message) || changed
+}
+
+// setTransitGatewayReady sets the Ready condition of the TransitGateway and
+// mirrors it into the plain status fields. It reports whether the status
+// changed.
+func setTransitGatewayReady(transitGateway *galacticv1alpha.TransitGateway, status metav1.ConditionStatus, reason, message string) bool {
+	changed := setCondition(&transitGateway.Status.Conditions, transitGateway.Generation, galacticv1alpha.ConditionReady, status, reason, message)
+	return mirrorReady(&transitGateway.Status.Ready, &transitGateway.Status.Reason, &transitGateway.Status.Message, &transitGateway.Status.ObservedGeneration,
+		transitGateway.Generation, status, reason, message) || changed
+}
+
+func mirrorReady(ready *bool, reason, message *string, observedGeneration *int64, generation int64, status metav1.ConditionStatus, newReason, newMessage string) bool {
+	newReady := status == metav1.ConditionTrue
+	if *ready == newReady && *reason == newReason && *message == newMessage && *observedGeneration == generation {
+		return false
+	}
+	*ready = newReady
+	*reason = newReason
+	*message = newMessage
+	*observedGeneration = generation
+	return true
+}
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/identifier"
+)
+
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=identifierclaims,verbs=get;list;watch;create;delete
+
+// vpcIdentifierScope is the scope of VPC identifiers, which are unique
+// cluster-wide.
+const vpcIdentifierScope = "vpc"
+
+// vpcAttachmentIdentifierScope returns the scope of the identifiers of the
+// VPCAttachments of a VPC, which are unique per VPC.
+func vpcAttachmentIdentifierScope(vpcIdentifier string) string {
+	return "vpcattachment-" + vpcIdentifier
+}
+
+// vpcAddressScope returns the scope of the addresses allocated to the
+// VPCAttachments of a VPC, which are unique per VPC.
+func vpcAddressScope(vpcIdentifier string) string {
+	return "vpcaddress-" + vpcIdentifier
+}
+
+// addressIdentifier returns the identifier an address is claimed by, the
+// hexadecimal representation of its bytes. Addresses may be given in CIDR
+// notation.
+func addressIdentifier(address string) (string, error) {
+	addr, err := netip.ParseAddr(strings.SplitN(address, "/", 2)[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	return hex.EncodeToString(addr.Unmap().AsSlice()), nil
+}
+
+// claimName returns the name of the IdentifierClaim for an identifier within
+// a scope.
+func claimName(scope, identifier string) string {
+	return scope + "-" + identifier
+}
+
+// releasedIdentifierClaims only passes deletions of IdentifierClaims, which
+// may unblock resources requesting the released identifier.
+var releasedIdentifierClaims = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	UpdateFunc:  func(event.UpdateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return true },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// claimedOrReleasedIdentifierClaims passes creations and deletions of
+// IdentifierClaims, which change the number of identifiers in use.
+var claimedOrReleasedIdentifierClaims = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return true },
+	UpdateFunc:  func(event.UpdateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return true },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// claimIdentifier reserves identifier within scope for claimant by creating
+// its IdentifierClaim. It reports false if the identifier is already held by
+// another resource. Claiming an identifier already held by claimant succeeds.
+func claimIdentifier(ctx context.Context, c client.Client, scope, identifier string, claimant client.Object) (bool, error) {
+	gvk, err := c.GroupVersionKindFor(claimant)
+	if err != nil {
+		return false, err
+	}
+	claim := &galacticv1alpha.IdentifierClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: claimName(scope, identifier),
+			Labels: map[string]string{
+				galacticv1alpha.IdentifierClaimClaimantLabel: string(claimant.GetUID()),
+				galacticv1alpha.IdentifierClaimScopeLabel:    scope,
+			},
+		},
+		Spec: galacticv1alpha.IdentifierClaimSpec{
+			Identifier: identifier,
+			Claimant: corev1.ObjectReference{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Namespace:  claimant.GetNamespace(),
+				Name:       claimant.GetName(),
+				UID:        claimant.GetUID(),
+			},
+		},
+	}
+	err = c.Create(ctx, claim)
+	if err == nil {
+		return true, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return false, err
+	}
+
+	// The cache may not have caught up with the existing claim, or the claim
+	// may have been released in the meantime. Either way the error requeues
+	// the claimant instead of reporting the identifier as held by another.
+	if err := c.Get(ctx, client.ObjectKeyFromObject(claim), claim); err != nil {
+		return false, err
+	}
+	return claim.Spec.Claimant.UID == claimant.GetUID(), nil
+}
+
+// claimedIdentifier returns the identifier held by claimant within scope, if
+// any. It allows to pick up a claim whose identifier never made it into the
+// status.
+func claimedIdentifier(ctx context.Context, c client.Client, scope string, claimant client.Object) (string, error) {
+	var claims galacticv1alpha.IdentifierClaimList
+	if err := c.List(ctx, &claims, client.MatchingLabels{
+		galacticv1alpha.IdentifierClaimClaimantLabel: string(claimant.GetUID()),
+		galacticv1alpha.IdentifierClaimScopeLabel:    scope,
+	}); err != nil {
+		return "", err
+	}
+	for _, claim := range claims.Items {
+		if claim.Spec.Claimant.UID == claimant.GetUID() && claim.DeletionTimestamp.IsZero() {
+			return claim.Spec.Identifier, nil
+		}
+	}
+	return "", nil
+}
+
+// claimedIdentifiers returns all identifiers claimed within scope.
+func claimedIdentifiers(ctx context.Context, c client.Client, scope string) ([]string, error) {
+	var claims galacticv1alpha.IdentifierClaimList
+	if err := c.List(ctx, &claims, client.MatchingLabels{galacticv1alpha.IdentifierClaimScopeLabel: scope}); err != nil {
+		return nil, err
+	}
+	identifiers := make([]string, 0, len(claims.Items))
+	for _, claim := range claims.Items {
+		identifiers = append(identifiers, claim.Spec.Identifier)
+	}
+	return identifiers, nil
+}
+
+// claimLowestFreeIdentifier claims the lowest identifier up to max that is not
+// claimed within scope yet. It returns identifier.ErrSpaceExhausted if every
+// identifier is claimed.
+func claimLowestFreeIdentifier(ctx context.Context, c client.Client, id *identifier.Identifier, scope string, max uint64, claimant client.Object) (string, error) {
+	used, err := claimedIdentifiers(ctx, c, scope)
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate, err := id.LowestFree(used, max)
+		if err != nil {
+			return "", err
+		}
+		claimed, err := claimIdentifier(ctx, c, scope, candidate, claimant)
+		if err != nil {
+			return "", err
+		}
+		if claimed {
+			return candidate, nil
+		}
+		used = append(used, candidate)
+	}
+}
+
+// releaseIdentifiersExcept deletes the IdentifierClaims held by claimant within
+// scope whose identifier is not listed in keep.
+func releaseIdentifiersExcept(ctx context.Context, c client.Client, scope string, claimant client.Object, keep []string) error {
+	var claims galacticv1alpha.IdentifierClaimList
+	if err := c.List(ctx, &claims, client.MatchingLabels{
+		galacticv1alpha.IdentifierClaimClaimantLabel: string(claimant.GetUID()),
+		galacticv1alpha.IdentifierClaimScopeLabel:    scope,
+	}); err != nil {
+		return err
+	}
+	for i := range claims.Items {
+		if claims.Items[i].Spec.Claimant.UID != claimant.GetUID() || slices.Contains(keep, claims.Items[i].Spec.Identifier) {
+			continue
+		}
+		if err := c.Delete(ctx, &claims.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseIdentifiers deletes all IdentifierClaims held by claimant.
+func releaseIdentifiers(ctx context.Context, c client.Client, claimant client.Object) error {
+	var claims galacticv1alpha.IdentifierClaimList
+	if err := c.List(ctx, &claims, client.MatchingLabels{
+		galacticv1alpha.IdentifierClaimClaimantLabel: string(claimant.GetUID()),
+	}); err != nil {
+		return err
+	}
+	for i := range claims.Items {
+		if claims.Items[i].Spec.Claimant.UID != claimant.GetUID() {
+			continue
+		}
+		if err := c.Delete(ctx, &claims.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/identifier"
+)
+
+var (
+	vpcAttachmentIdentifiersUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "galactic_vpc_attachment_identifiers_used",
+		Help: "Number of VPCAttachment identifiers claimed within a VPC",
+	}, []string{"namespace", "vpc"})
+
+	vpcAttachmentIdentifiersAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "galactic_vpc_attachment_identifiers_available",
+		Help: "Number of VPCAttachment identifiers still available within a VPC",
+	}, []string{"namespace", "vpc"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(vpcAttachmentIdentifiersUsed, vpcAttachmentIdentifiersAvailable)
+}
+
+// recordIdentifierUsage updates the identifier metrics of the VPC from the
+// IdentifierClaims of its VPCAttachments. The VPCReconciler records them
+// whenever such a claim is created or deleted.
+func recordIdentifierUsage(ctx context.Context, c client.Client, vpc *galacticv1alpha.VPC) error {
+	used, err := claimedIdentifiers(ctx, c, vpcAttachmentIdentifierScope(vpc.Status.Identifier))
+	if err != nil {
+		return err
+	}
+	usable := identifier.Usable(identifier.MaxVPCAttachment)
+	vpcAttachmentIdentifiersUsed.WithLabelValues(vpc.Namespace, vpc.Name).Set(float64(len(used)))
+	vpcAttachmentIdentifiersAvailable.WithLabelValues(vpc.Namespace, vpc.Name).Set(float64(usable - min(uint64(len(used)), usable)))
+	return nil
+}
+
+// forgetIdentifierUsage removes the identifier metrics of a deleted VPC.
+func forgetIdentifierUsage(vpc *galacticv1alpha.VPC) {
+	vpcAttachmentIdentifiersUsed.DeleteLabelValues(vpc.Namespace, vpc.Name)
+	vpcAttachmentIdentifiersAvailable.DeleteLabelValues(vpc.Namespace, vpc.Name)
+}
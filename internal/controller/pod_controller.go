@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/podnetworks"
+)
+
+const (
+	PodReasonVPCInterfacesReady    = "VPCInterfacesReady"
+	PodReasonVPCInterfacesNotReady = "VPCInterfacesNotReady"
+)
+
+// PodReconciler sets the VPC readiness gate of Pods from the network status
+// reported by Multus.
+type PodReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=get;update;patch
+
+func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pod corev1.Pod
+	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !hasVPCReadinessGate(&pod) || !podnetworks.PodIsActive(&pod) {
+		return ctrl.Result{}, nil
+	}
+
+	ready, message, err := podnetworks.VPCInterfacesReady(&pod)
+	if err != nil {
+		// The annotations will not fix themselves, report them on the condition
+		message = err.Error()
+	}
+	condition := corev1.PodCondition{
+		Type:    galacticv1alpha.PodConditionVPCReady,
+		Status:  corev1.ConditionFalse,
+		Reason:  PodReasonVPCInterfacesNotReady,
+		Message: message,
+	}
+	if ready {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = PodReasonVPCInterfacesReady
+	}
+
+	original := pod.DeepCopy()
+	if !setPodCondition(&pod, condition) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, r.Status().Patch(ctx, &pod, client.StrategicMergeFrom(original))
+}
+
+func hasVPCReadinessGate(pod *corev1.Pod) bool {
+	return slices.ContainsFunc(pod.Spec.ReadinessGates, func(gate corev1.PodReadinessGate) bool {
+		return gate.ConditionType == galacticv1alpha.PodConditionVPCReady
+	})
+}
+
+// setPodCondition sets the condition of the Pod and reports whether it
+// changed, keeping the transition time while the status stays the same.
+func setPodCondition(pod *corev1.Pod, condition corev1.PodCondition) bool {
+	i := slices.IndexFunc(pod.Status.Conditions, func(existing corev1.PodCondition) bool {
+		return existing.Type == condition.Type
+	})
+	if i < 0 {
+		condition.LastTransitionTime = metav1.Now()
+		pod.Status.Conditions = append(pod.Status.Conditions, condition)
+		return true
+	}
+	existing := &pod.Status.Conditions[i]
+	if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return false
+	}
+	condition.LastTransitionTime = existing.LastTransitionTime
+	if existing.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	*existing = condition
+	return true
+}
+
+// PodCacheByObject restricts the cached Pods to those the Pod webhook labelled
+// as attached to VPCAttachments, so that the cache does not grow with all the
+// other Pods of the cluster. Both the Pod and the VPCAttachment controller
+// only watch these Pods.
+func PodCacheByObject() map[client.Object]cache.ByObject {
+	return map[client.Object]cache.ByObject{
+		&corev1.Pod{}: {
+			Label: labels.SelectorFromSet(labels.Set{galacticv1alpha.VPCAttachedPodLabel: "true"}),
+		},
+	}
+}
+
+func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Pod{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			pod, ok := obj.(*corev1.Pod)
+			return ok && hasVPCReadinessGate(pod)
+		}))).
+		Named("pod").
+		Complete(r)
+}
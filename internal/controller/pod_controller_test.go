@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("Pod Controller", func() {
+	Context("When reconciling a Pod with the VPC readiness gate", func() {
+		ctx := context.Background()
+
+		podTypeNamespacedName := types.NamespacedName{
+			Name:      "readiness-pod",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			By("creating a pod attached to a VPCAttachment")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podTypeNamespacedName.Name,
+					Namespace: podTypeNamespacedName.Namespace,
+					Annotations: map[string]string{
+						galacticv1alpha.VPCAttachmentAnnotation: "readiness-attachment",
+						nadv1.NetworkAttachmentAnnot:            `[{"name":"readiness-attachment","namespace":"default","interface":"galactic0","ips":["10.8.8.1/24"]}]`,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "test-container",
+							Image: "test:latest",
+						},
+					},
+					ReadinessGates: []corev1.PodReadinessGate{
+						{ConditionType: galacticv1alpha.PodConditionVPCReady},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:      podTypeNamespacedName.Name,
+				Namespace: podTypeNamespacedName.Namespace,
+			}}, client.GracePeriodSeconds(0))).To(Succeed())
+		})
+
+		vpcReadyCondition := func() *corev1.PodCondition {
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, podTypeNamespacedName, pod)).To(Succeed())
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == galacticv1alpha.PodConditionVPCReady {
+					return &condition
+				}
+			}
+			return nil
+		}
+
+		It("should set the condition once the VPC interface is reported", func() {
+			podControllerReconciler := &PodReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("reconciling before Multus reports the network status")
+			_, err := podControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: podTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			condition := vpcReadyCondition()
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(PodReasonVPCInterfacesNotReady))
+
+			By("reporting the VPC interface in the network status")
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, podTypeNamespacedName, pod)).To(Succeed())
+			pod.Annotations[nadv1.NetworkStatusAnnot] = `[{"name":"default/readiness-attachment","interface":"galactic0","ips":["10.8.8.1"]}]`
+			Expect(k8sClient.Update(ctx, pod)).To(Succeed())
+
+			_, err = podControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: podTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			condition = vpcReadyCondition()
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(PodReasonVPCInterfacesReady))
+		})
+	})
+})
@@ -25,14 +25,18 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/fieldindex"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -45,6 +49,7 @@ var (
 	testEnv   *envtest.Environment
 	cfg       *rest.Config
 	k8sClient client.Client
+	k8sCache  cache.Cache
 )
 
 func TestControllers(t *testing.T) {
@@ -80,7 +85,25 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	By("starting a cache serving the field indexes used by the reconcilers")
+	k8sCache, err = cache.New(cfg, cache.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(fieldindex.Setup(ctx, k8sCache)).To(Succeed())
+	go func() {
+		defer GinkgoRecover()
+		Expect(k8sCache.Start(ctx)).To(Succeed())
+	}()
+	Expect(k8sCache.WaitForCacheSync(ctx)).To(BeTrue())
+
+	apiReader, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	k8sClient, err = client.New(cfg, client.Options{
+		Scheme: scheme.Scheme,
+		Cache: &client.CacheOptions{
+			Reader: &fieldIndexReader{Reader: apiReader, cache: k8sCache},
+		},
+	})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
 })
@@ -92,6 +115,42 @@ var _ = AfterSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 })
 
+// fieldIndexReader serves lists using field selectors from the cache, which
+// knows about the field indexes, and every other read from the API server so
+// that the tests observe their own writes immediately.
+type fieldIndexReader struct {
+	client.Reader
+	cache cache.Cache
+}
+
+func (r *fieldIndexReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if listOpts.FieldSelector != nil && !listOpts.FieldSelector.Empty() {
+		return r.cache.List(ctx, list, opts...)
+	}
+	return r.Reader.List(ctx, list, opts...)
+}
+
+// waitForCache waits until the cache serving the field indexes has caught up
+// with the state of obj on the API server, including its deletion.
+func waitForCache(ctx context.Context, obj client.Object) {
+	GinkgoHelper()
+	key := client.ObjectKeyFromObject(obj)
+	Eventually(func(g Gomega) {
+		current := obj.DeepCopyObject().(client.Object)
+		err := k8sClient.Get(ctx, key, current)
+		cached := obj.DeepCopyObject().(client.Object)
+		cacheErr := k8sCache.Get(ctx, key, cached)
+		if errors.IsNotFound(err) {
+			g.Expect(errors.IsNotFound(cacheErr)).To(BeTrue())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cacheErr).NotTo(HaveOccurred())
+		g.Expect(cached.GetResourceVersion()).To(Equal(current.GetResourceVersion()))
+	}).Should(Succeed())
+}
+
 // getFirstFoundEnvTestBinaryDir locates the first binary in the specified path.
 // ENVTEST-based tests depend on specific binaries, usually located in paths set by
 // controller-runtime. When running tests directly (e.g., via an IDE) without using
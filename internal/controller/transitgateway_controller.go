@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/transitgateway"
+)
+
+// TransitGatewayReconciler reports the associations of TransitGateways and
+// whether their route tables propagate overlapping networks. The effective
+// routes are rendered by the VPCAttachmentReconciler.
+type TransitGatewayReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=transitgateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=transitgateways/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcs,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+func (r *TransitGatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var transitGateway galacticv1alpha.TransitGateway
+	if err := r.Get(ctx, req.NamespacedName, &transitGateway); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !transitGateway.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	var vpcs galacticv1alpha.VPCList
+	if err := r.List(ctx, &vpcs); err != nil {
+		return ctrl.Result{}, err
+	}
+	associated, denied, err := r.permittedAssociations(ctx, &transitGateway, transitgateway.Associated(transitGateway.Name, vpcs.Items))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	changed := false
+	if transitGateway.Status.AssociatedVPCs != int32(len(associated)) {
+		transitGateway.Status.AssociatedVPCs = int32(len(associated))
+		changed = true
+	}
+	status, reason, message := r.evaluate(&transitGateway, associated, denied)
+	if setTransitGatewayReady(&transitGateway, status, reason, message) {
+		changed = true
+	}
+
+	if changed {
+		return ctrl.Result{}, r.Status().Update(ctx, &transitGateway)
+	}
+	return ctrl.Result{}, nil
+}
+
+// permittedAssociations splits the associated VPCs into those that may
+// associate with their route table and those whose namespace the route table
+// does not select. VPCs associated with missing route tables count as
+// permitted, evaluate reports them.
+func (r *TransitGatewayReconciler) permittedAssociations(ctx context.Context, transitGateway *galacticv1alpha.TransitGateway,
+	associated []galacticv1alpha.VPC) ([]galacticv1alpha.VPC, []galacticv1alpha.VPC, error) {
+	var permitted, denied []galacticv1alpha.VPC
+	for _, vpc := range associated {
+		routeTable := transitgateway.RouteTable(transitGateway, vpc.Spec.TransitGateway.RouteTable)
+		if routeTable == nil {
+			permitted = append(permitted, vpc)
+			continue
+		}
+		ok, err := transitgateway.Permitted(ctx, r.Client, routeTable, vpc.Namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			permitted = append(permitted, vpc)
+		} else {
+			denied = append(denied, vpc)
+		}
+	}
+	return permitted, denied, nil
+}
+
+// evaluate checks that the associated VPCs use existing route tables that
+// permit their association and that no route table makes VPCs with
+// overlapping networks reach each other.
+func (r *TransitGatewayReconciler) evaluate(transitGateway *galacticv1alpha.TransitGateway, associated, denied []galacticv1alpha.VPC) (metav1.ConditionStatus, string, string) {
+	var missing []string
+	for i := range associated {
+		if transitgateway.RouteTable(transitGateway, associated[i].Spec.TransitGateway.RouteTable) == nil {
+			missing = append(missing, fmt.Sprintf("%s (route table %s)",
+				client.ObjectKeyFromObject(&associated[i]), associated[i].Spec.TransitGateway.RouteTable))
+		}
+	}
+	if len(missing) > 0 {
+		return metav1.ConditionFalse, galacticv1alpha.TransitGatewayReasonRouteTableNotFound,
+			fmt.Sprintf("VPCs associated with missing route tables: %s", summarizeNames(missing))
+	}
+	if len(denied) > 0 {
+		names := make([]string, 0, len(denied))
+		for i := range denied {
+			names = append(names, fmt.Sprintf("%s (route table %s)",
+				client.ObjectKeyFromObject(&denied[i]), denied[i].Spec.TransitGateway.RouteTable))
+		}
+		return metav1.ConditionFalse, galacticv1alpha.TransitGatewayReasonAssociationNotPermitted,
+			fmt.Sprintf("VPCs associated with route tables not selecting their namespace: %s", summarizeNames(names))
+	}
+
+	for i := range transitGateway.Spec.RouteTables {
+		routeTable := &transitGateway.Spec.RouteTables[i]
+		// The VPCs associated with the route table reach all propagated VPCs,
+		// which therefore must not overlap with each other or with them
+		propagated := transitgateway.Propagated(routeTable, associated)
+		overlap, ok := transitgateway.FindOverlap(propagated)
+		for j := 0; j < len(associated) && !ok; j++ {
+			if associated[j].Spec.TransitGateway.RouteTable != routeTable.Name {
+				continue
+			}
+			others := slices.DeleteFunc(slices.Clone(propagated), func(vpc galacticv1alpha.VPC) bool {
+				return client.ObjectKeyFromObject(&vpc) == client.ObjectKeyFromObject(&associated[j])
+			})
+			overlap, ok = transitgateway.FindOverlap(append(others, associated[j]))
+		}
+		if ok {
+			return metav1.ConditionFalse, galacticv1alpha.TransitGatewayReasonNetworksOverlap,
+				fmt.Sprintf("route table %s connects network %s of VPC %s with network %s of VPC %s",
+					routeTable.Name, overlap.Network, overlap.VPC, overlap.OtherNetwork, overlap.OtherVPC)
+		}
+	}
+
+	return metav1.ConditionTrue, galacticv1alpha.ReasonReady, fmt.Sprintf("%d VPCs associated", len(associated))
+}
+
+func (r *TransitGatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&galacticv1alpha.TransitGateway{}).
+		Watches(&galacticv1alpha.VPC{}, handler.EnqueueRequestsFromMapFunc(transitGatewayForVPC)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.transitGatewaysOfNamespace)).
+		Named("transitgateway").
+		Complete(r)
+}
+
+// transitGatewayForVPC maps a VPC to the TransitGateway it is associated with.
+// Updates map both the old and the new VPC, so a TransitGateway also learns
+// about VPCs leaving it.
+func transitGatewayForVPC(_ context.Context, obj client.Object) []reconcile.Request {
+	vpc, ok := obj.(*galacticv1alpha.VPC)
+	if !ok || vpc.Spec.TransitGateway == nil {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: vpc.Spec.TransitGateway.Name}}}
+}
+
+// transitGatewaysOfNamespace maps a Namespace to the TransitGateways its VPCs
+// are associated with, whose namespace selectors may select it or no longer.
+func (r *TransitGatewayReconciler) transitGatewaysOfNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	var vpcs galacticv1alpha.VPCList
+	if err := r.List(ctx, &vpcs, client.InNamespace(obj.GetName())); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCs of Namespace", "namespace", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range vpcs.Items {
+		for _, request := range transitGatewayForVPC(ctx, &vpcs.Items[i]) {
+			if !slices.Contains(requests, request) {
+				requests = append(requests, request)
+			}
+		}
+	}
+	return requests
+}
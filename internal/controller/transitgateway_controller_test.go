@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("TransitGateway Controller", func() {
+	Context("When reconciling a TransitGateway", func() {
+		ctx := context.Background()
+
+		transitGatewayNamespacedName := types.NamespacedName{Name: "hub"}
+		vpcs := []*galacticv1alpha.VPC{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "hub-spoke-a", Namespace: "default"},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks:       []string{"10.41.0.0/16"},
+					TransitGateway: &galacticv1alpha.VPCTransitGatewayAssociation{Name: "hub", RouteTable: "spokes"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "hub-spoke-b", Namespace: "default"},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks:       []string{"10.41.1.0/24"},
+					TransitGateway: &galacticv1alpha.VPCTransitGatewayAssociation{Name: "hub", RouteTable: "spokes"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "hub-shared", Namespace: "default"},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks:       []string{"10.42.0.0/16"},
+					TransitGateway: &galacticv1alpha.VPCTransitGatewayAssociation{Name: "hub", RouteTable: "shared"},
+				},
+			},
+		}
+
+		BeforeEach(func() {
+			By("creating the TransitGateway and the associated VPCs")
+			Expect(k8sClient.Create(ctx, &galacticv1alpha.TransitGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: transitGatewayNamespacedName.Name},
+				Spec: galacticv1alpha.TransitGatewaySpec{
+					RouteTables: []galacticv1alpha.TransitGatewayRouteTable{
+						{Name: "spokes", PropagateFrom: []string{"shared"}, NamespaceSelector: &metav1.LabelSelector{}},
+					},
+				},
+			})).To(Succeed())
+			for _, vpc := range vpcs {
+				Expect(k8sClient.Create(ctx, vpc.DeepCopy())).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			for _, vpc := range vpcs {
+				Expect(k8sClient.Delete(ctx, vpc.DeepCopy())).To(Succeed())
+			}
+			Expect(k8sClient.Delete(ctx, &galacticv1alpha.TransitGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: transitGatewayNamespacedName.Name},
+			})).To(Succeed())
+		})
+
+		reconcileTransitGateway := func() *galacticv1alpha.TransitGateway {
+			controllerReconciler := &TransitGatewayReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: transitGatewayNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &galacticv1alpha.TransitGateway{}
+			Expect(k8sClient.Get(ctx, transitGatewayNamespacedName, resource)).To(Succeed())
+			return resource
+		}
+
+		It("should report associations with missing route tables, without permission and with overlapping networks", func() {
+			By("reconciling with the shared VPC associated with a missing route table")
+			resource := reconcileTransitGateway()
+			Expect(resource.Status.AssociatedVPCs).To(Equal(int32(3)))
+			Expect(resource.Status.Ready).To(BeFalse())
+			Expect(resource.Status.Reason).To(Equal(galacticv1alpha.TransitGatewayReasonRouteTableNotFound))
+
+			By("adding the route table of the shared VPC without permitting any namespace")
+			resource.Spec.RouteTables = append(resource.Spec.RouteTables,
+				galacticv1alpha.TransitGatewayRouteTable{Name: "shared", PropagateFrom: []string{"spokes"}})
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			resource = reconcileTransitGateway()
+			Expect(resource.Status.Ready).To(BeFalse())
+			Expect(resource.Status.Reason).To(Equal(galacticv1alpha.TransitGatewayReasonAssociationNotPermitted))
+			Expect(resource.Status.AssociatedVPCs).To(Equal(int32(2)))
+
+			By("permitting the namespace of the shared VPC to associate with the route table")
+			resource.Spec.RouteTables[1].NamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kubernetes.io/metadata.name": "default"},
+			}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			resource = reconcileTransitGateway()
+			Expect(resource.Status.Ready).To(BeFalse())
+			Expect(resource.Status.AssociatedVPCs).To(Equal(int32(3)))
+			Expect(resource.Status.Reason).To(Equal(galacticv1alpha.TransitGatewayReasonNetworksOverlap))
+
+			By("keeping the overlapping spokes from reaching each other")
+			resource.Spec.RouteTables[1].PropagateFrom = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			resource = reconcileTransitGateway()
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Reason).To(Equal(galacticv1alpha.ReasonReady))
+		})
+
+		It("should map a Namespace to the TransitGateways of its VPCs", func() {
+			controllerReconciler := &TransitGatewayReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+			Expect(controllerReconciler.transitGatewaysOfNamespace(ctx, namespace)).To(ConsistOf(
+				reconcile.Request{NamespacedName: transitGatewayNamespacedName}))
+		})
+	})
+})
@@ -2,14 +2,25 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/fieldindex"
 	"github.com/datum-cloud/galactic-operator/internal/identifier"
 )
 
@@ -31,47 +42,227 @@ func (r *VPCReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if !vpc.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &vpc)
+	}
+
+	if controllerutil.AddFinalizer(&vpc, galacticv1alpha.VPCFinalizer) {
+		if err := r.Update(ctx, &vpc); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	changed := false
+
 	// We only assign an identifier once
 	if vpc.Status.Identifier == "" {
-		var existingVpcs galacticv1alpha.VPCList
-		if err := r.List(ctx, &existingVpcs, &client.ListOptions{}); err != nil {
+		vpcIdentifier, err := claimedIdentifier(ctx, r.Client, vpcIdentifierScope, &vpc)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
-		existingIdentifiers := vpcsToIdentifiers(existingVpcs)
 
-		for i := 0; i <= MaxIdentifierAttemptsVPC; i++ {
-			if i == MaxIdentifierAttemptsVPC {
-				return ctrl.Result{}, fmt.Errorf("could not find an unused identifier after %d attempts", MaxIdentifierAttemptsVPC)
+		if vpcIdentifier == "" && vpc.Spec.Identifier != "" {
+			requested, err := r.Identifier.FromString(vpc.Spec.Identifier, identifier.MaxVPC)
+			if err != nil {
+				return ctrl.Result{}, r.setIdentifierNotAssigned(ctx, &vpc, galacticv1alpha.ReasonInvalidIdentifier,
+					fmt.Sprintf("requested identifier %s is invalid: %v", vpc.Spec.Identifier, err))
+			}
+			claimed, err := claimIdentifier(ctx, r.Client, vpcIdentifierScope, requested, &vpc)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !claimed {
+				return ctrl.Result{}, r.setIdentifierNotAssigned(ctx, &vpc, galacticv1alpha.ReasonIdentifierConflict,
+					fmt.Sprintf("requested identifier %s is claimed by another VPC", requested))
+			}
+			vpcIdentifier = requested
+		}
+
+		for i := 0; i < MaxIdentifierAttemptsVPC && vpcIdentifier == ""; i++ {
+			candidate, _ := r.Identifier.ForVPC()
+			claimed, err := claimIdentifier(ctx, r.Client, vpcIdentifierScope, candidate, &vpc)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if claimed {
+				vpcIdentifier = candidate
+			}
+		}
+		if vpcIdentifier == "" {
+			// Random attempts fail more and more often as the space fills up
+			vpcIdentifier, err = claimLowestFreeIdentifier(ctx, r.Client, r.Identifier, vpcIdentifierScope, identifier.MaxVPC, &vpc)
+			if errors.Is(err, identifier.ErrSpaceExhausted) {
+				if updateErr := r.setIdentifierNotAssigned(ctx, &vpc, galacticv1alpha.ReasonIdentifiersExhausted, err.Error()); updateErr != nil {
+					return ctrl.Result{}, updateErr
+				}
 			}
-			if vpc.Status.Identifier != "" && !slices.Contains(existingIdentifiers, vpc.Status.Identifier) {
-				break
+			if err != nil {
+				return ctrl.Result{}, err
 			}
-			vpc.Status.Identifier, _ = r.Identifier.ForVPC()
 		}
+		vpc.Status.Identifier = vpcIdentifier
+		changed = true
+	} else {
+		// Identifiers assigned before they were claimed get claimed retroactively
+		claimed, err := claimIdentifier(ctx, r.Client, vpcIdentifierScope, vpc.Status.Identifier, &vpc)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !claimed {
+			return ctrl.Result{}, r.setIdentifierNotAssigned(ctx, &vpc, galacticv1alpha.ReasonIdentifierConflict,
+				fmt.Sprintf("identifier %s is claimed by another VPC", vpc.Status.Identifier))
+		}
+	}
 
-		vpc.Status.Ready = true
+	if setCondition(&vpc.Status.Conditions, vpc.Generation, galacticv1alpha.ConditionIdentifierAssigned,
+		metav1.ConditionTrue, galacticv1alpha.ReasonIdentifierAssigned, fmt.Sprintf("identifier %s assigned", vpc.Status.Identifier)) {
+		changed = true
+	}
+	if setVPCReady(&vpc, metav1.ConditionTrue, galacticv1alpha.ReasonReady, "VPC is ready") {
+		changed = true
+	}
 
+	if changed {
 		if err := r.Status().Update(ctx, &vpc); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
+	if err := recordIdentifierUsage(ctx, r.Client, &vpc); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// setIdentifierNotAssigned marks the VPC as not ready because no identifier
+// could be assigned and persists the status if it changed.
+func (r *VPCReconciler) setIdentifierNotAssigned(ctx context.Context, vpc *galacticv1alpha.VPC, reason, message string) error {
+	changed := setCondition(&vpc.Status.Conditions, vpc.Generation, galacticv1alpha.ConditionIdentifierAssigned,
+		metav1.ConditionFalse, reason, message)
+	if setVPCReady(vpc, metav1.ConditionFalse, reason, message) || changed {
+		return r.Status().Update(ctx, vpc)
+	}
+	return nil
+}
+
 func (r *VPCReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&galacticv1alpha.VPC{}).
+		Watches(&galacticv1alpha.VPCAttachment{}, handler.EnqueueRequestsFromMapFunc(vpcForVPCAttachment)).
+		Watches(&galacticv1alpha.IdentifierClaim{}, handler.EnqueueRequestsFromMapFunc(r.vpcsRequestingIdentifier),
+			builder.WithPredicates(releasedIdentifierClaims)).
+		Watches(&galacticv1alpha.IdentifierClaim{}, handler.EnqueueRequestsFromMapFunc(r.vpcOfVPCAttachmentIdentifierClaim),
+			builder.WithPredicates(claimedOrReleasedIdentifierClaims)).
 		Named("vpc").
 		Complete(r)
 }
 
-func vpcsToIdentifiers(vpcs galacticv1alpha.VPCList) []string {
-	identifiers := make([]string, 0, len(vpcs.Items))
-	for _, vpc := range vpcs.Items {
-		if vpc.Status.Identifier != "" {
-			identifiers = append(identifiers, vpc.Status.Identifier)
+// finalize removes the finalizer once no VPCAttachment references the VPC
+// anymore. Depending on the deletion policy the remaining VPCAttachments are
+// deleted or reported as blocking the deletion.
+func (r *VPCReconciler) finalize(ctx context.Context, vpc *galacticv1alpha.VPC) error {
+	if !controllerutil.ContainsFinalizer(vpc, galacticv1alpha.VPCFinalizer) {
+		return nil
+	}
+
+	var existingVpcAttachments galacticv1alpha.VPCAttachmentList
+	if err := r.List(ctx, &existingVpcAttachments, client.MatchingFields{
+		fieldindex.VPCAttachmentVPC: fieldindex.VPCKey(vpc.Namespace, vpc.Name),
+	}); err != nil {
+		return err
+	}
+	vpcAttachments := existingVpcAttachments.Items
+
+	if len(vpcAttachments) == 0 {
+		if err := releaseIdentifiers(ctx, r.Client, vpc); err != nil {
+			return err
+		}
+		forgetIdentifierUsage(vpc)
+		controllerutil.RemoveFinalizer(vpc, galacticv1alpha.VPCFinalizer)
+		return r.Update(ctx, vpc)
+	}
+
+	names := make([]string, 0, len(vpcAttachments))
+	for i := range vpcAttachments {
+		names = append(names, client.ObjectKeyFromObject(&vpcAttachments[i]).String())
+		if vpc.Spec.DeletionPolicy == galacticv1alpha.VPCDeletionPolicyCascade && vpcAttachments[i].DeletionTimestamp.IsZero() {
+			if err := r.Delete(ctx, &vpcAttachments[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
 		}
 	}
-	return identifiers
+
+	if meta.SetStatusCondition(&vpc.Status.Conditions, metav1.Condition{
+		Type:               galacticv1alpha.VPCConditionDeletionBlocked,
+		Status:             metav1.ConditionTrue,
+		Reason:             galacticv1alpha.VPCReasonVPCAttachmentsExist,
+		Message:            fmt.Sprintf("waiting for VPCAttachments to be deleted: %s", summarizeNames(names)),
+		ObservedGeneration: vpc.Generation,
+	}) {
+		return r.Status().Update(ctx, vpc)
+	}
+	return nil
+}
+
+// vpcsRequestingIdentifier maps a released VPC IdentifierClaim to the VPCs
+// requesting its identifier.
+func (r *VPCReconciler) vpcsRequestingIdentifier(ctx context.Context, obj client.Object) []reconcile.Request {
+	claim, ok := obj.(*galacticv1alpha.IdentifierClaim)
+	if !ok || claim.Spec.Claimant.Kind != "VPC" {
+		return nil
+	}
+	var vpcs galacticv1alpha.VPCList
+	if err := r.List(ctx, &vpcs, client.MatchingFields{fieldindex.VPCIdentifier: fieldindex.IdentifierKey(claim.Spec.Identifier)}); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCs requesting an identifier", "identifier", claim.Spec.Identifier)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(vpcs.Items))
+	for _, vpc := range vpcs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpc)})
+	}
+	return requests
+}
+
+// vpcOfVPCAttachmentIdentifierClaim maps an IdentifierClaim of a VPCAttachment
+// identifier to the VPC the identifier is unique in, so that the identifier
+// metrics of the VPC follow every claim and release.
+func (r *VPCReconciler) vpcOfVPCAttachmentIdentifierClaim(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpcIdentifier, ok := strings.CutPrefix(obj.GetLabels()[galacticv1alpha.IdentifierClaimScopeLabel], vpcAttachmentIdentifierScope(""))
+	if !ok || vpcIdentifier == "" {
+		return nil
+	}
+	var vpcs galacticv1alpha.VPCList
+	if err := r.List(ctx, &vpcs, client.MatchingFields{fieldindex.VPCAssignedIdentifier: vpcIdentifier}); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCs of an identifier scope", "identifier", vpcIdentifier)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(vpcs.Items))
+	for _, vpc := range vpcs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpc)})
+	}
+	return requests
+}
+
+// vpcForVPCAttachment maps a VPCAttachment to the VPC it references.
+func vpcForVPCAttachment(_ context.Context, obj client.Object) []reconcile.Request {
+	vpcAttachment, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Namespace: vpcAttachment.Spec.VPC.Namespace,
+		Name:      vpcAttachment.Spec.VPC.Name,
+	}}}
+}
+
+// summarizeNames joins names for use in a condition message, eliding all but
+// the first few.
+func summarizeNames(names []string) string {
+	const maxNames = 10
+	slices.Sort(names)
+	if len(names) <= maxNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxNames], ", "), len(names)-maxNames)
 }
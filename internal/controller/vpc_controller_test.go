@@ -6,8 +6,11 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -79,6 +82,17 @@ var _ = Describe("VPC Controller", func() {
 
 				Expect(resource.Status.Ready).To(BeTrue())
 				Expect(resource.Status.Identifier).To(Equal(result_identifiers[resourceNum]))
+				Expect(resource.Status.Reason).To(Equal(galacticv1alpha.ReasonReady))
+				Expect(resource.Status.ObservedGeneration).To(Equal(resource.Generation))
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, galacticv1alpha.ConditionReady)).To(BeTrue())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, galacticv1alpha.ConditionIdentifierAssigned)).To(BeTrue())
+
+				By("checking that the identifier is claimed by the resource")
+				claim := &galacticv1alpha.IdentifierClaim{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "vpc-" + result_identifiers[resourceNum]}, claim)).To(Succeed())
+				Expect(claim.Spec.Identifier).To(Equal(result_identifiers[resourceNum]))
+				Expect(claim.Spec.Claimant.Name).To(Equal(resourceName))
+				Expect(claim.Spec.Claimant.UID).To(Equal(resource.UID))
 			})
 		}
 
@@ -88,9 +102,178 @@ var _ = Describe("VPC Controller", func() {
 			err := k8sClient.List(ctx, &vpcs)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(vpcs.Items).To(HaveLen(len(result_identifiers)))
+			controllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
 			for _, vpc := range vpcs.Items {
 				Expect(k8sClient.Delete(ctx, &vpc)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: client.ObjectKeyFromObject(&vpc),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(&vpc), &vpc))).To(BeTrue())
+			}
+
+			By("checking that the identifiers have been released")
+			for _, vpcIdentifier := range result_identifiers {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "vpc-" + vpcIdentifier}, &galacticv1alpha.IdentifierClaim{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("When deleting a resource with VPCAttachments", func() {
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      "deletion-vpc",
+			Namespace: "default",
+		}
+
+		controllerReconciler := &VPCReconciler{
+			Client:     k8sClient,
+			Scheme:     k8sClient.Scheme(),
+			Identifier: identifier.NewFromSeed(424242),
+		}
+
+		createVPC := func(deletionPolicy galacticv1alpha.VPCDeletionPolicy) *galacticv1alpha.VPC {
+			vpc := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      typeNamespacedName.Name,
+					Namespace: typeNamespacedName.Namespace,
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks:       []string{"10.4.4.0/24"},
+					DeletionPolicy: deletionPolicy,
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			vpcAttachment := &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deletion-vpcattachment",
+					Namespace: typeNamespacedName.Namespace,
+					Labels:    map[string]string{"test": "deletion"},
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       typeNamespacedName.Name,
+						Namespace:  typeNamespacedName.Namespace,
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.4.4.1/24"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+			waitForCache(ctx, vpcAttachment)
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, vpc)).To(Succeed())
+			Expect(vpc.Finalizers).To(ContainElement(galacticv1alpha.VPCFinalizer))
+			Expect(k8sClient.Delete(ctx, vpc)).To(Succeed())
+			return vpc
+		}
+
+		It("should block the deletion until the VPCAttachments are gone", func() {
+			vpc := createVPC(galacticv1alpha.VPCDeletionPolicyBlock)
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, vpc)).To(Succeed())
+			condition := meta.FindStatusCondition(vpc.Status.Conditions, galacticv1alpha.VPCConditionDeletionBlocked)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(galacticv1alpha.VPCReasonVPCAttachmentsExist))
+			Expect(condition.Message).To(ContainSubstring("default/deletion-vpcattachment"))
+
+			By("deleting the VPCAttachment")
+			cleanupVPC(ctx, typeNamespacedName, client.MatchingLabels{"test": "deletion"})
+		})
+
+		It("should delete the VPCAttachments with the Cascade deletion policy", func() {
+			createVPC(galacticv1alpha.VPCDeletionPolicyCascade)
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			vpcAttachmentNamespacedName := types.NamespacedName{Name: "deletion-vpcattachment", Namespace: "default"}
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, vpcAttachmentNamespacedName, vpcAttachment))).To(BeTrue())
+			waitForCache(ctx, &galacticv1alpha.VPCAttachment{ObjectMeta: metav1.ObjectMeta{
+				Name:      vpcAttachmentNamespacedName.Name,
+				Namespace: vpcAttachmentNamespacedName.Namespace,
+			}})
+
+			By("reconciling the VPC again once its VPCAttachments are gone")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &galacticv1alpha.VPC{}))).To(BeTrue())
+		})
+	})
+
+	Context("When reconciling resources requesting an identifier", func() {
+		ctx := context.Background()
+
+		controllerReconciler := &VPCReconciler{
+			Client:     k8sClient,
+			Scheme:     k8sClient.Scheme(),
+			Identifier: identifier.NewFromSeed(424242),
+		}
+
+		createVPC := func(name string) types.NamespacedName {
+			vpc := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks:   []string{"10.5.5.0/24"},
+					Identifier: "3039",
+				},
 			}
+			Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+			typeNamespacedName := client.ObjectKeyFromObject(vpc)
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			return typeNamespacedName
+		}
+
+		It("should adopt the requested identifier and report conflicts", func() {
+			By("reconciling a VPC requesting an identifier")
+			first := createVPC("requested-vpc-0")
+			vpc := &galacticv1alpha.VPC{}
+			Expect(k8sClient.Get(ctx, first, vpc)).To(Succeed())
+			Expect(vpc.Status.Ready).To(BeTrue())
+			Expect(vpc.Status.Identifier).To(Equal("000000003039"))
+
+			By("reconciling a second VPC requesting the same identifier")
+			second := createVPC("requested-vpc-1")
+			Expect(k8sClient.Get(ctx, second, vpc)).To(Succeed())
+			Expect(vpc.Status.Ready).To(BeFalse())
+			Expect(vpc.Status.Identifier).To(BeEmpty())
+			condition := meta.FindStatusCondition(vpc.Status.Conditions, galacticv1alpha.ConditionIdentifierAssigned)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(galacticv1alpha.ReasonIdentifierConflict))
+
+			By("deleting the first VPC and reconciling the second one again")
+			cleanupVPC(ctx, first, client.MatchingLabels{"test": "requested"})
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: second})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, second, vpc)).To(Succeed())
+			Expect(vpc.Status.Ready).To(BeTrue())
+			Expect(vpc.Status.Identifier).To(Equal("000000003039"))
+
+			cleanupVPC(ctx, second, client.MatchingLabels{"test": "requested"})
 		})
 	})
 })
@@ -3,15 +3,28 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"slices"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -19,11 +32,22 @@ import (
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
 	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+	"github.com/datum-cloud/galactic-operator/internal/fieldindex"
+	"github.com/datum-cloud/galactic-operator/internal/grant"
 	"github.com/datum-cloud/galactic-operator/internal/identifier"
+	"github.com/datum-cloud/galactic-operator/internal/ipam"
+	"github.com/datum-cloud/galactic-operator/internal/peering"
+	"github.com/datum-cloud/galactic-operator/internal/podnetworks"
+	"github.com/datum-cloud/galactic-operator/internal/securitygroup"
+	"github.com/datum-cloud/galactic-operator/internal/transitgateway"
 )
 
 const MaxIdentifierAttemptsVPCAttachment = 100
 
+// StampedVPCAttachmentGracePeriod is how long a VPCAttachment stamped out from
+// a template waits for its Pod to be created before it is deleted.
+const StampedVPCAttachmentGracePeriod = 30 * time.Second
+
 type VPCAttachmentReconciler struct {
 	client.Client
 	Scheme     *runtime.Scheme
@@ -34,47 +58,241 @@ type VPCAttachmentReconciler struct {
 // +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcattachments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcattachments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcattachments/finalizers,verbs=update
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcattachmentgrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcpeerings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=transitgateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcsecuritygroups,verbs=get;list;watch
 // +kubebuilder:rbac:groups=k8s.cni.cncf.io,resources=network-attachment-definitions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *VPCAttachmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var vpcAttachment galacticv1alpha.VPCAttachment
 	if err := r.Get(ctx, req.NamespacedName, &vpcAttachment); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+
+	if !vpcAttachment.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &vpcAttachment)
+	}
+
+	// VPCAttachments admitted without the defaulting webhook may omit the
+	// namespace of the VPC, which defaults to their own
+	defaulted := false
+	if vpcAttachment.Spec.VPC.Namespace == "" {
+		vpcAttachment.Spec.VPC.Namespace = vpcAttachment.Namespace
+		defaulted = true
+	}
+	changed := controllerutil.AddFinalizer(&vpcAttachment, galacticv1alpha.VPCAttachmentFinalizer) || defaulted
+
+	// VPCAttachments stamped out from a template are owned by their Pod, so
+	// that they are garbage collected together with it
+	var requeueAfter time.Duration
+	if podName, stamped := vpcAttachment.Labels[galacticv1alpha.VPCAttachmentPodLabel]; stamped {
+		var pod corev1.Pod
+		err := r.Get(ctx, types.NamespacedName{Namespace: vpcAttachment.Namespace, Name: podName}, &pod)
+		switch {
+		case apierrors.IsNotFound(err):
+			// The Pod is created after its admission stamped out the VPCAttachment,
+			// or not at all if a later admission check rejected it
+			age := time.Since(vpcAttachment.CreationTimestamp.Time)
+			if age >= StampedVPCAttachmentGracePeriod {
+				return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, &vpcAttachment))
+			}
+			requeueAfter = StampedVPCAttachmentGracePeriod - age
+		case err != nil:
+			return ctrl.Result{}, err
+		case !slices.ContainsFunc(vpcAttachment.OwnerReferences, func(ref metav1.OwnerReference) bool { return ref.UID == pod.UID }):
+			// Replaces the reference to an earlier Pod of the same name
+			if err := controllerutil.SetOwnerReference(&pod, &vpcAttachment, r.Scheme); err != nil {
+				return ctrl.Result{}, err
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := r.Update(ctx, &vpcAttachment); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	original := vpcAttachment.Status.DeepCopy()
+	if err := r.bindPod(ctx, &vpcAttachment); err != nil {
+		return ctrl.Result{}, err
+	}
+	result, err := r.reconcileVPCAttachment(ctx, &vpcAttachment)
+	if !equality.Semantic.DeepEqual(*original, vpcAttachment.Status) {
+		if updateErr := r.Status().Update(ctx, &vpcAttachment); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+	}
+	if requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+	return result, err
+}
+
+// reconcileVPCAttachment resolves the VPC, assigns identifier and addresses
+// and syncs the NetworkAttachmentDefinition. The outcome is only recorded in
+// the status of vpcAttachment, Reconcile persists it afterwards.
+func (r *VPCAttachmentReconciler) reconcileVPCAttachment(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) (ctrl.Result, error) {
 	vpcNamespacedName := types.NamespacedName{
 		Namespace: vpcAttachment.Spec.VPC.Namespace,
 		Name:      vpcAttachment.Spec.VPC.Name,
 	}
+	permitted, err := grant.VPCAttachmentPermitted(ctx, r.Client, vpcAttachment.Namespace, vpcAttachment.Spec.VPC)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !permitted {
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionVPCResolved,
+			galacticv1alpha.VPCAttachmentReasonNotPermitted,
+			fmt.Sprintf("no VPCAttachmentGrant in namespace %s permits references to VPC %s", vpcNamespacedName.Namespace, vpcNamespacedName))
+		// A revoked grant disconnects the VPCAttachment from the VPC
+		return ctrl.Result{}, r.deleteNetworkAttachmentDefinition(ctx, vpcAttachment)
+	}
+
 	var vpc galacticv1alpha.VPC
 	if err := r.Get(ctx, vpcNamespacedName, &vpc); err != nil {
-		return ctrl.Result{}, err
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionVPCResolved,
+			galacticv1alpha.VPCAttachmentReasonVPCNotFound, fmt.Sprintf("VPC %s not found", vpcNamespacedName))
+		return ctrl.Result{}, nil
 	}
 	if !vpc.Status.Ready {
-		return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionVPCResolved,
+			galacticv1alpha.VPCAttachmentReasonVPCNotReady, fmt.Sprintf("VPC %s is not ready", vpcNamespacedName))
+		return ctrl.Result{}, nil
 	}
+	setCondition(&vpcAttachment.Status.Conditions, vpcAttachment.Generation, galacticv1alpha.VPCAttachmentConditionVPCResolved,
+		metav1.ConditionTrue, galacticv1alpha.VPCAttachmentReasonVPCResolved, fmt.Sprintf("VPC %s is ready", vpcNamespacedName))
 
 	// We only assign an identifier once
 	if vpcAttachment.Status.Identifier == "" {
-		var existingVpcAttachments galacticv1alpha.VPCAttachmentList
-		if err := r.List(ctx, &existingVpcAttachments, &client.ListOptions{}); err != nil {
+		vpcAttachmentIdentifier, err := claimedIdentifier(ctx, r.Client, vpcAttachmentIdentifierScope(vpc.Status.Identifier), vpcAttachment)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
-		existingIdentifiers := vpcAttachmentsToIdentifiers(vpc, existingVpcAttachments)
 
-		for i := 0; i <= MaxIdentifierAttemptsVPCAttachment; i++ {
-			if i == MaxIdentifierAttemptsVPCAttachment {
-				return ctrl.Result{}, fmt.Errorf("could not find an unused identifier after %d attempts", MaxIdentifierAttemptsVPCAttachment)
+		if vpcAttachmentIdentifier == "" && vpcAttachment.Spec.Identifier != "" {
+			requested, err := r.Identifier.FromString(vpcAttachment.Spec.Identifier, identifier.MaxVPCAttachment)
+			if err != nil {
+				setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.ConditionIdentifierAssigned, galacticv1alpha.ReasonInvalidIdentifier,
+					fmt.Sprintf("requested identifier %s is invalid: %v", vpcAttachment.Spec.Identifier, err))
+				return ctrl.Result{}, nil
+			}
+			claimed, err := claimIdentifier(ctx, r.Client, vpcAttachmentIdentifierScope(vpc.Status.Identifier), requested, vpcAttachment)
+			if err != nil {
+				return ctrl.Result{}, err
 			}
-			if vpcAttachment.Status.Identifier != "" && !slices.Contains(existingIdentifiers, vpcAttachment.Status.Identifier) {
-				break
+			if !claimed {
+				setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.ConditionIdentifierAssigned, galacticv1alpha.ReasonIdentifierConflict,
+					fmt.Sprintf("requested identifier %s is claimed by another VPCAttachment of VPC %s", requested, vpcNamespacedName))
+				return ctrl.Result{}, nil
 			}
-			vpcAttachment.Status.Identifier, _ = r.Identifier.ForVPCAttachment()
+			vpcAttachmentIdentifier = requested
 		}
 
-		if err := r.Status().Update(ctx, &vpcAttachment); err != nil {
+		scope := vpcAttachmentIdentifierScope(vpc.Status.Identifier)
+		for i := 0; i < MaxIdentifierAttemptsVPCAttachment && vpcAttachmentIdentifier == ""; i++ {
+			candidate, _ := r.Identifier.ForVPCAttachment()
+			claimed, err := claimIdentifier(ctx, r.Client, scope, candidate, vpcAttachment)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if claimed {
+				vpcAttachmentIdentifier = candidate
+			}
+		}
+		if vpcAttachmentIdentifier == "" {
+			// Random attempts fail more and more often as the space fills up
+			vpcAttachmentIdentifier, err = claimLowestFreeIdentifier(ctx, r.Client, r.Identifier, scope, identifier.MaxVPCAttachment, vpcAttachment)
+			if errors.Is(err, identifier.ErrSpaceExhausted) {
+				setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.ConditionIdentifierAssigned, galacticv1alpha.ReasonIdentifiersExhausted,
+					fmt.Sprintf("all identifiers of VPC %s are in use", vpcNamespacedName))
+			}
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		vpcAttachment.Status.Identifier = vpcAttachmentIdentifier
+	} else {
+		// Identifiers assigned before they were claimed get claimed retroactively
+		claimed, err := claimIdentifier(ctx, r.Client, vpcAttachmentIdentifierScope(vpc.Status.Identifier),
+			vpcAttachment.Status.Identifier, vpcAttachment)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
+		if !claimed {
+			setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.ConditionIdentifierAssigned, galacticv1alpha.ReasonIdentifierConflict,
+				fmt.Sprintf("identifier %s is claimed by another VPCAttachment of VPC %s", vpcAttachment.Status.Identifier, vpcNamespacedName))
+			return ctrl.Result{}, nil
+		}
 	}
+	setCondition(&vpcAttachment.Status.Conditions, vpcAttachment.Generation, galacticv1alpha.ConditionIdentifierAssigned,
+		metav1.ConditionTrue, galacticv1alpha.ReasonIdentifierAssigned, fmt.Sprintf("identifier %s assigned", vpcAttachment.Status.Identifier))
+
+	var excluded []string
+	var conflictingVpcAttachment *galacticv1alpha.VPCAttachment
+	var conflictingAddress string
+	for {
+		if err := r.assignAddresses(ctx, vpc, vpcAttachment, excluded); err != nil {
+			setVPCAttachmentNotReady(vpcAttachment, "", galacticv1alpha.VPCAttachmentReasonAddressAllocationFailed, err.Error())
+			return ctrl.Result{}, err
+		}
+		conflictingVpcAttachment, conflictingAddress, err = r.findAddressConflict(ctx, *vpcAttachment)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// Allocations working from a stale cache may pick an address an older
+		// VPCAttachment lists in its spec, which is then allocated anew
+		if conflictingVpcAttachment == nil || len(vpcAttachment.Spec.Interface.Addresses) > 0 {
+			break
+		}
+		excluded = append(excluded, conflictingAddress)
+	}
+	if conflictingVpcAttachment != nil {
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionConflict, galacticv1alpha.VPCAttachmentReasonAddressInUse,
+			fmt.Sprintf("address %s is already in use by VPCAttachment %s/%s",
+				conflictingAddress, conflictingVpcAttachment.Namespace, conflictingVpcAttachment.Name))
+		// New Pods must not come up with the duplicate address
+		return ctrl.Result{}, r.deleteNetworkAttachmentDefinition(ctx, vpcAttachment)
+	}
+	meta.RemoveStatusCondition(&vpcAttachment.Status.Conditions, galacticv1alpha.VPCAttachmentConditionConflict)
+
+	peers, err := r.reachableVPCs(ctx, &vpc, vpcAttachment)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Security groups select among the VPCAttachments of all VPCs reachable
+	// through the interface
+	vpcs := []types.NamespacedName{vpcNamespacedName}
+	for i := range peers {
+		vpcs = append(vpcs, client.ObjectKeyFromObject(&peers[i]))
+	}
+	firewall, missing, err := securitygroup.Firewall(ctx, r.Client, vpcAttachment, vpcs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(missing) > 0 {
+		// Rendering without the missing rules would leave the traffic open, and
+		// so would new Pods attaching with the rules rendered before
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionNetworkAttachmentDefinitionSynced,
+			galacticv1alpha.VPCAttachmentReasonSecurityGroupNotFound,
+			fmt.Sprintf("VPCSecurityGroups not found in namespace %s: %s", vpcAttachment.Namespace, summarizeNames(missing)))
+		return ctrl.Result{}, r.deleteNetworkAttachmentDefinition(ctx, vpcAttachment)
+	}
+
+	cniPluginConfig, err := cniconfig.CNIConfigForVPCAttachment(vpc, *vpcAttachment, peers, firewall, r.MTU)
+	if err != nil {
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionNetworkAttachmentDefinitionSynced,
+			galacticv1alpha.VPCAttachmentReasonRenderFailed, err.Error())
+		return ctrl.Result{}, err
+	}
+	cniPluginConfigJson, _ := json.Marshal(cniPluginConfig)
 
 	nad := &nadv1.NetworkAttachmentDefinition{
 		ObjectMeta: metav1.ObjectMeta{
@@ -82,51 +300,584 @@ func (r *VPCAttachmentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			Namespace: vpcAttachment.Namespace,
 		},
 	}
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, nad, func() error {
-		cniPluginConfig, err := cniconfig.CNIConfigForVPCAttachment(vpc, vpcAttachment, r.MTU)
-		if err != nil {
-			return err
-		}
-		cniPluginConfigJson, _ := json.Marshal(cniPluginConfig)
-
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, nad, func() error {
 		nad.Spec = nadv1.NetworkAttachmentDefinitionSpec{
 			Config: string(cniPluginConfigJson),
 		}
 
-		if err := controllerutil.SetControllerReference(&vpcAttachment, nad, r.Scheme); err != nil {
+		if err := controllerutil.SetControllerReference(vpcAttachment, nad, r.Scheme); err != nil {
 			return err
 		}
 		return nil
 	})
 	if err != nil {
+		setVPCAttachmentNotReady(vpcAttachment, galacticv1alpha.VPCAttachmentConditionNetworkAttachmentDefinitionSynced,
+			galacticv1alpha.VPCAttachmentReasonSyncFailed, err.Error())
 		return ctrl.Result{}, err
 	}
+	setCondition(&vpcAttachment.Status.Conditions, vpcAttachment.Generation, galacticv1alpha.VPCAttachmentConditionNetworkAttachmentDefinitionSynced,
+		metav1.ConditionTrue, galacticv1alpha.VPCAttachmentReasonSynced, fmt.Sprintf("NetworkAttachmentDefinition %s/%s is up to date", nad.Namespace, nad.Name))
 
-	if !vpcAttachment.Status.Ready {
-		vpcAttachment.Status.Ready = true
-		if err := r.Status().Update(ctx, &vpcAttachment); err != nil {
-			return ctrl.Result{}, err
-		}
-	}
-
+	setVPCAttachmentReady(vpcAttachment, metav1.ConditionTrue, galacticv1alpha.ReasonReady, "VPCAttachment is ready")
 	return ctrl.Result{}, nil
 }
 
 func (r *VPCAttachmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&galacticv1alpha.VPCAttachment{}).
+		Owns(&nadv1.NetworkAttachmentDefinition{}).
+		Watches(&galacticv1alpha.VPC{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsOfVPC)).
+		Watches(&galacticv1alpha.IdentifierClaim{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsRequestingIdentifier),
+			builder.WithPredicates(releasedIdentifierClaims)).
+		Watches(&galacticv1alpha.VPCAttachment{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsSharingAddresses)).
+		Watches(&galacticv1alpha.VPCAttachmentGrant{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsOfGrant)).
+		Watches(&galacticv1alpha.VPCPeering{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsOfPeering)).
+		Watches(&galacticv1alpha.TransitGateway{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsOfTransitGateway)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsOfNamespace)).
+		Watches(&galacticv1alpha.VPCSecurityGroup{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsOfSecurityGroup)).
+		Watches(&galacticv1alpha.VPCAttachment{}, handler.EnqueueRequestsFromMapFunc(r.vpcAttachmentsSelecting)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(vpcAttachmentsForPod),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				_, exists := obj.GetAnnotations()[galacticv1alpha.VPCAttachmentAnnotation]
+				return exists
+			}))).
 		Named("vpcattachment").
 		Complete(r)
 }
 
-func vpcAttachmentsToIdentifiers(vpc galacticv1alpha.VPC, vpcAttachments galacticv1alpha.VPCAttachmentList) []string {
-	identifiers := make([]string, 0, len(vpcAttachments.Items))
+// reachableVPCs returns the VPCs connected to vpc by a VPCPeering or reachable
+// through its TransitGateway, and records the routes to their networks as the
+// effective routes of vpcAttachment. Peerings take precedence over routes
+// through the TransitGateway to VPCs with overlapping networks.
+func (r *VPCAttachmentReconciler) reachableVPCs(ctx context.Context, vpc *galacticv1alpha.VPC, vpcAttachment *galacticv1alpha.VPCAttachment) ([]galacticv1alpha.VPC, error) {
+	peers, err := peering.PeerVPCs(ctx, r.Client, client.ObjectKeyFromObject(vpc))
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := transitgateway.ReachableVPCs(ctx, r.Client, vpc)
+	if err != nil {
+		return nil, err
+	}
+
+	var effectiveRoutes []galacticv1alpha.VPCAttachmentEffectiveRoute
+	var networks []string
+	for _, peer := range peers {
+		for _, network := range peer.Spec.Networks {
+			effectiveRoutes = append(effectiveRoutes, galacticv1alpha.VPCAttachmentEffectiveRoute{
+				Destination: network,
+				VPC:         client.ObjectKeyFromObject(&peer).String(),
+			})
+		}
+		networks = append(networks, peer.Spec.Networks...)
+	}
+	for _, route := range reachable {
+		if _, _, overlap := peering.OverlappingNetworks(route.VPC.Spec.Networks, networks); overlap {
+			continue
+		}
+		for _, network := range route.VPC.Spec.Networks {
+			effectiveRoutes = append(effectiveRoutes, galacticv1alpha.VPCAttachmentEffectiveRoute{
+				Destination:    network,
+				VPC:            client.ObjectKeyFromObject(&route.VPC).String(),
+				TransitGateway: route.TransitGateway,
+				RouteTable:     route.RouteTable,
+			})
+		}
+		networks = append(networks, route.VPC.Spec.Networks...)
+		peers = append(peers, route.VPC)
+	}
+	vpcAttachment.Status.EffectiveRoutes = effectiveRoutes
+	return peers, nil
+}
+
+// assignAddresses records the interface addresses in the status, allocating
+// them from the address ranges if the spec does not list any. Allocated
+// addresses are reserved through IdentifierClaims, so that allocations working
+// from a stale cache never hand out the same address twice, and are released
+// together with the VPCAttachment. Addresses listed in excluded are allocated
+// anew.
+func (r *VPCAttachmentReconciler) assignAddresses(ctx context.Context, vpc galacticv1alpha.VPC, vpcAttachment *galacticv1alpha.VPCAttachment, excluded []string) error {
+	scope := vpcAddressScope(vpc.Status.Identifier)
+	if len(vpcAttachment.Spec.Interface.Addresses) > 0 {
+		vpcAttachment.Status.Addresses = vpcAttachment.Spec.Interface.Addresses
+		return releaseIdentifiersExcept(ctx, r.Client, scope, vpcAttachment, nil)
+	}
+
+	ranges := vpcAttachment.Spec.Interface.AddressRanges
+	if len(ranges) == 0 {
+		ranges = vpc.Spec.Networks
+	}
+	allocate := ipam.Allocate
+	if index := vpcAttachment.Spec.Interface.AddressIndex; index != nil {
+		allocate = func(networks, used []string) ([]string, error) {
+			return ipam.AllocateNth(networks, used, int(*index))
+		}
+	}
+
+	// Addresses allocated before they were claimed get claimed retroactively
+	var addresses []string
+	for _, address := range vpcAttachment.Status.Addresses {
+		if !ipam.Contains(ranges, address) || !ipam.Contains(vpc.Spec.Networks, address) || slices.Contains(excluded, address) ||
+			slices.ContainsFunc(addresses, sameFamily(address)) {
+			continue
+		}
+		claimed, err := claimAddress(ctx, r.Client, scope, address, vpcAttachment)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			addresses = append(addresses, address)
+		}
+	}
+
+	var existingVpcAttachments galacticv1alpha.VPCAttachmentList
+	if err := r.List(ctx, &existingVpcAttachments, client.MatchingFields{
+		fieldindex.VPCAttachmentVPC: fieldindex.VPCKey(vpc.Namespace, vpc.Name),
+	}); err != nil {
+		return err
+	}
+	used := append(vpcAttachmentsToAddresses(vpc, *vpcAttachment, existingVpcAttachments), excluded...)
+	for {
+		allocated, err := allocate(ranges, append(used, addresses...))
+		if err != nil {
+			return err
+		}
+		// Ranges may be smaller than the VPC networks, which are on-link either way
+		for i := range allocated {
+			allocated[i] = ipam.WithPrefixOf(vpc.Spec.Networks, allocated[i])
+		}
+		complete := true
+		for _, address := range allocated {
+			if slices.ContainsFunc(addresses, sameFamily(address)) {
+				continue
+			}
+			claimed, err := claimAddress(ctx, r.Client, scope, address, vpcAttachment)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				used = append(used, address)
+				complete = false
+				continue
+			}
+			addresses = append(addresses, address)
+		}
+		if complete {
+			// Keep the order of the address families in the VPC networks
+			slices.SortStableFunc(addresses, func(a, b string) int {
+				return slices.IndexFunc(allocated, sameFamily(a)) - slices.IndexFunc(allocated, sameFamily(b))
+			})
+			break
+		}
+	}
+
+	vpcAttachment.Status.Addresses = addresses
+	identifiers := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		identifier, err := addressIdentifier(address)
+		if err != nil {
+			return err
+		}
+		identifiers = append(identifiers, identifier)
+	}
+	return releaseIdentifiersExcept(ctx, r.Client, scope, vpcAttachment, identifiers)
+}
+
+// deleteNetworkAttachmentDefinition disconnects the VPCAttachment from the VPC
+// by deleting its NetworkAttachmentDefinition, so that no new Pod attaches to
+// it. Pods already attached keep their interfaces.
+func (r *VPCAttachmentReconciler) deleteNetworkAttachmentDefinition(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) error {
+	nad := &nadv1.NetworkAttachmentDefinition{ObjectMeta: metav1.ObjectMeta{
+		Name:      vpcAttachment.Name,
+		Namespace: vpcAttachment.Namespace,
+	}}
+	return client.IgnoreNotFound(r.Delete(ctx, nad))
+}
+
+// claimAddress reserves address within scope for claimant, see
+// claimIdentifier.
+func claimAddress(ctx context.Context, c client.Client, scope, address string, claimant client.Object) (bool, error) {
+	identifier, err := addressIdentifier(address)
+	if err != nil {
+		return false, err
+	}
+	return claimIdentifier(ctx, c, scope, identifier, claimant)
+}
+
+// sameFamily returns a function reporting whether an address belongs to the
+// address family of address.
+func sameFamily(address string) func(string) bool {
+	is4 := func(address string) bool {
+		ip, _, err := net.ParseCIDR(address)
+		return err == nil && ip.To4() != nil
+	}
+	family := is4(address)
+	return func(other string) bool {
+		return is4(other) == family
+	}
+}
+
+// finalize removes the finalizer once no active Pod references the
+// VPCAttachment anymore, reporting the remaining Pods otherwise.
+func (r *VPCAttachmentReconciler) finalize(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) error {
+	if !controllerutil.ContainsFinalizer(vpcAttachment, galacticv1alpha.VPCAttachmentFinalizer) {
+		return nil
+	}
+
+	pods, err := r.activePods(ctx, vpcAttachment)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, client.ObjectKeyFromObject(&pod).String())
+	}
+
+	if len(names) == 0 {
+		if err := releaseIdentifiers(ctx, r.Client, vpcAttachment); err != nil {
+			return err
+		}
+		controllerutil.RemoveFinalizer(vpcAttachment, galacticv1alpha.VPCAttachmentFinalizer)
+		return r.Update(ctx, vpcAttachment)
+	}
+
+	if meta.SetStatusCondition(&vpcAttachment.Status.Conditions, metav1.Condition{
+		Type:               galacticv1alpha.VPCAttachmentConditionDeletionBlocked,
+		Status:             metav1.ConditionTrue,
+		Reason:             galacticv1alpha.VPCAttachmentReasonPodsRunning,
+		Message:            fmt.Sprintf("waiting for Pods using the VPCAttachment to stop: %s", summarizeNames(names)),
+		ObservedGeneration: vpcAttachment.Generation,
+	}) {
+		return r.Status().Update(ctx, vpcAttachment)
+	}
+	return nil
+}
+
+// findAddressConflict returns a VPCAttachment of the same VPC created before
+// vpcAttachment that uses one of its interface addresses, along with that
+// address. The older VPCAttachment keeps the address.
+func (r *VPCAttachmentReconciler) findAddressConflict(ctx context.Context, vpcAttachment galacticv1alpha.VPCAttachment) (*galacticv1alpha.VPCAttachment, string, error) {
+	for _, address := range cniconfig.InterfaceAddresses(vpcAttachment) {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			continue
+		}
+		var vpcAttachments galacticv1alpha.VPCAttachmentList
+		if err := r.List(ctx, &vpcAttachments, client.MatchingFields{
+			fieldindex.VPCAttachmentAddress: fieldindex.VPCAddressKey(vpcAttachment.Spec.VPC.Namespace, vpcAttachment.Spec.VPC.Name, ip),
+		}); err != nil {
+			return nil, "", err
+		}
+		for i := range vpcAttachments.Items {
+			other := &vpcAttachments.Items[i]
+			if other.UID != vpcAttachment.UID && createdBefore(other, &vpcAttachment) {
+				return other, address, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// vpcAttachmentsSharingAddresses maps a VPCAttachment to the other
+// VPCAttachments using one of its addresses, so that conflicts are
+// re-evaluated when an attachment changes or goes away.
+func (r *VPCAttachmentReconciler) vpcAttachmentsSharingAddresses(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, key := range fieldindex.VPCAttachmentAddressKeys(obj) {
+		var vpcAttachments galacticv1alpha.VPCAttachmentList
+		if err := r.List(ctx, &vpcAttachments, client.MatchingFields{fieldindex.VPCAttachmentAddress: key}); err != nil {
+			logf.FromContext(ctx).Error(err, "unable to list VPCAttachments sharing an address", "key", key)
+			continue
+		}
+		for _, vpcAttachment := range vpcAttachments.Items {
+			if vpcAttachment.UID != obj.GetUID() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpcAttachment)})
+			}
+		}
+	}
+	return requests
+}
+
+// vpcAttachmentsOfVPC maps a VPC to the VPCAttachments referencing it or a
+// VPC it is peered with, as their configuration includes its networks.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfVPC(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpcs := []types.NamespacedName{client.ObjectKeyFromObject(obj)}
+	// Peerings are requested from both sides, so the peerings in the namespace
+	// of the VPC name all its peers
+	var vpcPeerings galacticv1alpha.VPCPeeringList
+	if err := r.List(ctx, &vpcPeerings, client.InNamespace(obj.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCPeerings of VPC", "vpc", client.ObjectKeyFromObject(obj))
+	}
+	for i := range vpcPeerings.Items {
+		if peering.VPC(&vpcPeerings.Items[i]) == vpcs[0] {
+			vpcs = append(vpcs, peering.PeerVPC(&vpcPeerings.Items[i]))
+		}
+	}
+	// Updates map both the old and the new VPC, so the VPCs of a TransitGateway
+	// also learn about VPCs leaving it
+	if vpc, ok := obj.(*galacticv1alpha.VPC); ok && vpc.Spec.TransitGateway != nil {
+		vpcs = append(vpcs, r.vpcsOfTransitGateway(ctx, vpc.Spec.TransitGateway.Name)...)
+	}
+	return r.vpcAttachmentsOfVPCs(ctx, vpcs)
+}
+
+// vpcAttachmentsOfTransitGateway maps a TransitGateway to the VPCAttachments of
+// the VPCs associated with it.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfTransitGateway(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.vpcAttachmentsOfVPCs(ctx, r.vpcsOfTransitGateway(ctx, obj.GetName()))
+}
+
+// vpcAttachmentsOfNamespace maps a Namespace to the VPCAttachments of the VPCs
+// associated with the TransitGateways its VPCs are associated with. Route
+// tables select the namespaces whose VPCs they connect, so its labels decide
+// whether these VPCs reach its VPCs and the other way round.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	var vpcs galacticv1alpha.VPCList
+	if err := r.List(ctx, &vpcs, client.InNamespace(obj.GetName())); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCs of Namespace", "namespace", obj.GetName())
+		return nil
+	}
+	var transitGateways []string
+	for _, vpc := range vpcs.Items {
+		if vpc.Spec.TransitGateway != nil && !slices.Contains(transitGateways, vpc.Spec.TransitGateway.Name) {
+			transitGateways = append(transitGateways, vpc.Spec.TransitGateway.Name)
+		}
+	}
+	var associated []types.NamespacedName
+	for _, transitGateway := range transitGateways {
+		associated = append(associated, r.vpcsOfTransitGateway(ctx, transitGateway)...)
+	}
+	return r.vpcAttachmentsOfVPCs(ctx, associated)
+}
+
+// vpcsOfTransitGateway returns the VPCs associated with the TransitGateway.
+func (r *VPCAttachmentReconciler) vpcsOfTransitGateway(ctx context.Context, name string) []types.NamespacedName {
+	var vpcs galacticv1alpha.VPCList
+	if err := r.List(ctx, &vpcs); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCs of TransitGateway", "transitGateway", name)
+		return nil
+	}
+	var associated []types.NamespacedName
+	for _, vpc := range transitgateway.Associated(name, vpcs.Items) {
+		associated = append(associated, client.ObjectKeyFromObject(&vpc))
+	}
+	return associated
+}
+
+// vpcAttachmentsOfPeering maps a VPCPeering to the VPCAttachments of both
+// VPCs it connects.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfPeering(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpcPeering, ok := obj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil
+	}
+	return r.vpcAttachmentsOfVPCs(ctx, []types.NamespacedName{peering.VPC(vpcPeering), peering.PeerVPC(vpcPeering)})
+}
+
+// vpcAttachmentsOfVPCs maps VPCs to the VPCAttachments referencing them.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfVPCs(ctx context.Context, vpcs []types.NamespacedName) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, vpc := range vpcs {
+		var vpcAttachments galacticv1alpha.VPCAttachmentList
+		if err := r.List(ctx, &vpcAttachments, client.MatchingFields{
+			fieldindex.VPCAttachmentVPC: fieldindex.VPCKey(vpc.Namespace, vpc.Name),
+		}); err != nil {
+			logf.FromContext(ctx).Error(err, "unable to list VPCAttachments of VPC", "vpc", vpc)
+			continue
+		}
+		for _, vpcAttachment := range vpcAttachments.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpcAttachment)})
+		}
+	}
+	return requests
+}
+
+// vpcAttachmentsOfGrant maps a VPCAttachmentGrant to the VPCAttachments in the
+// namespaces it lists that reference VPCs in the namespace of the grant.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpcAttachmentGrant, ok := obj.(*galacticv1alpha.VPCAttachmentGrant)
+	if !ok {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, from := range vpcAttachmentGrant.Spec.From {
+		var vpcAttachments galacticv1alpha.VPCAttachmentList
+		if err := r.List(ctx, &vpcAttachments, client.InNamespace(from.Namespace)); err != nil {
+			logf.FromContext(ctx).Error(err, "unable to list VPCAttachments of VPCAttachmentGrant",
+				"vpcAttachmentGrant", client.ObjectKeyFromObject(obj))
+			return nil
+		}
+		for _, vpcAttachment := range vpcAttachments.Items {
+			if vpcAttachment.Spec.VPC.Namespace == vpcAttachmentGrant.Namespace {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpcAttachment)})
+			}
+		}
+	}
+	return requests
+}
+
+// vpcAttachmentsOfSecurityGroup maps a VPCSecurityGroup to the VPCAttachments
+// referencing it.
+func (r *VPCAttachmentReconciler) vpcAttachmentsOfSecurityGroup(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.vpcAttachmentsReferencingSecurityGroups(ctx, obj.GetNamespace(), []string{obj.GetName()})
+}
+
+// vpcAttachmentsSelecting maps a VPCAttachment to the VPCAttachments whose
+// security groups select it, as their firewall rules include its addresses.
+// Updates map both the old and the new VPCAttachment, so a VPCAttachment
+// whose labels no longer match is removed from the rules as well.
+func (r *VPCAttachmentReconciler) vpcAttachmentsSelecting(ctx context.Context, obj client.Object) []reconcile.Request {
+	var securityGroups galacticv1alpha.VPCSecurityGroupList
+	if err := r.List(ctx, &securityGroups, client.InNamespace(obj.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCSecurityGroups", "namespace", obj.GetNamespace())
+		return nil
+	}
+	var names []string
+	for _, securityGroup := range securityGroups.Items {
+		if securityGroupSelects(&securityGroup, labels.Set(obj.GetLabels())) {
+			names = append(names, securityGroup.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return r.vpcAttachmentsReferencingSecurityGroups(ctx, obj.GetNamespace(), names)
+}
+
+// vpcAttachmentsReferencingSecurityGroups returns the VPCAttachments in the
+// namespace referencing one of the named VPCSecurityGroups.
+func (r *VPCAttachmentReconciler) vpcAttachmentsReferencingSecurityGroups(ctx context.Context, namespace string, names []string) []reconcile.Request {
+	var vpcAttachments galacticv1alpha.VPCAttachmentList
+	if err := r.List(ctx, &vpcAttachments, client.InNamespace(namespace)); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCAttachments of VPCSecurityGroups", "namespace", namespace)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, vpcAttachment := range vpcAttachments.Items {
+		if slices.ContainsFunc(vpcAttachment.Spec.SecurityGroups, func(name string) bool { return slices.Contains(names, name) }) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpcAttachment)})
+		}
+	}
+	return requests
+}
+
+// securityGroupSelects reports whether a peer of a rule of the security group
+// selects VPCAttachments with the given labels.
+func securityGroupSelects(securityGroup *galacticv1alpha.VPCSecurityGroup, set labels.Set) bool {
+	for _, rule := range slices.Concat(securityGroup.Spec.Ingress, securityGroup.Spec.Egress) {
+		for _, peer := range rule.Peers {
+			if peer.VPCAttachmentSelector == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(peer.VPCAttachmentSelector)
+			if err == nil && selector.Matches(set) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// vpcAttachmentsRequestingIdentifier maps a released VPCAttachment
+// IdentifierClaim to the VPCAttachments requesting its identifier.
+func (r *VPCAttachmentReconciler) vpcAttachmentsRequestingIdentifier(ctx context.Context, obj client.Object) []reconcile.Request {
+	claim, ok := obj.(*galacticv1alpha.IdentifierClaim)
+	if !ok || claim.Spec.Claimant.Kind != "VPCAttachment" ||
+		!strings.HasPrefix(claim.Labels[galacticv1alpha.IdentifierClaimScopeLabel], vpcAttachmentIdentifierScope("")) {
+		return nil
+	}
+	var vpcAttachments galacticv1alpha.VPCAttachmentList
+	if err := r.List(ctx, &vpcAttachments, client.MatchingFields{
+		fieldindex.VPCAttachmentIdentifier: fieldindex.IdentifierKey(claim.Spec.Identifier),
+	}); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCAttachments requesting an identifier", "identifier", claim.Spec.Identifier)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(vpcAttachments.Items))
+	for _, vpcAttachment := range vpcAttachments.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vpcAttachment)})
+	}
+	return requests
+}
+
+// vpcAttachmentsForPod maps a Pod to the VPCAttachments it references.
+func vpcAttachmentsForPod(_ context.Context, obj client.Object) []reconcile.Request {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	names := vpcAttachmentNamesForPod(pod)
+	requests := make([]reconcile.Request, 0, len(names))
+	for _, name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: pod.Namespace,
+			Name:      name,
+		}})
+	}
+	return requests
+}
+
+// activePods returns the active Pods using the VPCAttachment.
+func (r *VPCAttachmentReconciler) activePods(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) ([]corev1.Pod, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(vpcAttachment.Namespace)); err != nil {
+		return nil, err
+	}
+	return podnetworks.ActivePodsUsingVPCAttachment(pods.Items, vpcAttachment.Name), nil
+}
+
+// bindPod records the Pod bound to an Exclusive VPCAttachment in its status.
+// The binding is kept while the Pod is active and otherwise passes on to the
+// oldest remaining Pod.
+func (r *VPCAttachmentReconciler) bindPod(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) error {
+	if vpcAttachment.Spec.BindingMode != galacticv1alpha.VPCAttachmentBindingModeExclusive {
+		vpcAttachment.Status.BoundPod = ""
+		return nil
+	}
+
+	pods, err := r.activePods(ctx, vpcAttachment)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(pods, func(pod corev1.Pod) bool { return pod.Name == vpcAttachment.Status.BoundPod }) {
+		return nil
+	}
+	if len(pods) == 0 {
+		vpcAttachment.Status.BoundPod = ""
+		return nil
+	}
+	oldest := slices.MinFunc(pods, func(a, b corev1.Pod) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	vpcAttachment.Status.BoundPod = oldest.Name
+	return nil
+}
+
+// vpcAttachmentNamesForPod returns the names of the VPCAttachments referenced
+// by the Pod annotation.
+func vpcAttachmentNamesForPod(pod *corev1.Pod) []string {
+	// Pods with an invalid annotation are rejected by the webhook
+	names, _ := podnetworks.VPCAttachmentNames(pod.Annotations)
+	return names
+}
+
+func createdBefore(a, b *galacticv1alpha.VPCAttachment) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+}
+
+func vpcAttachmentsToAddresses(vpc galacticv1alpha.VPC, self galacticv1alpha.VPCAttachment, vpcAttachments galacticv1alpha.VPCAttachmentList) []string {
+	addresses := make([]string, 0, len(vpcAttachments.Items))
 	for _, vpcAttachment := range vpcAttachments.Items {
-		if vpcAttachment.Status.Identifier != "" &&
+		if vpcAttachment.UID != self.UID &&
 			vpcAttachment.Spec.VPC.Name == vpc.Name &&
 			vpcAttachment.Spec.VPC.Namespace == vpc.Namespace {
-			identifiers = append(identifiers, vpcAttachment.Status.Identifier)
+			addresses = append(addresses, cniconfig.InterfaceAddresses(vpcAttachment)...)
 		}
 	}
-	return identifiers
+	return addresses
 }
@@ -2,12 +2,16 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
@@ -16,6 +20,7 @@ import (
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
 	"github.com/datum-cloud/galactic-operator/internal/identifier"
 )
 
@@ -132,9 +137,26 @@ var _ = Describe("VPCAttachment Controller", func() {
 				Expect(err).NotTo(HaveOccurred())
 				if run == 1 {
 					Expect(resource.Status.Ready).To(BeFalse())
+					Expect(resource.Status.Reason).To(Equal(galacticv1alpha.VPCAttachmentReasonVPCNotReady))
+					Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, galacticv1alpha.VPCAttachmentConditionVPCResolved)).To(BeTrue())
 				} else {
 					Expect(resource.Status.Ready).To(BeTrue())
 					Expect(resource.Status.Identifier).To(Equal("e513"))
+					Expect(resource.Status.Reason).To(Equal(galacticv1alpha.ReasonReady))
+					for _, conditionType := range []string{
+						galacticv1alpha.ConditionReady,
+						galacticv1alpha.ConditionIdentifierAssigned,
+						galacticv1alpha.VPCAttachmentConditionVPCResolved,
+						galacticv1alpha.VPCAttachmentConditionNetworkAttachmentDefinitionSynced,
+					} {
+						Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, conditionType)).To(BeTrue(), conditionType)
+					}
+
+					vpc := &galacticv1alpha.VPC{}
+					Expect(k8sClient.Get(ctx, vpcTypeNamespacedName, vpc)).To(Succeed())
+					claim := &galacticv1alpha.IdentifierClaim{}
+					Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "vpcattachment-" + vpc.Status.Identifier + "-e513"}, claim)).To(Succeed())
+					Expect(claim.Spec.Claimant.UID).To(Equal(resource.UID))
 
 					nadResource := &nadv1.NetworkAttachmentDefinition{}
 					err = k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, nadResource)
@@ -145,3 +167,1241 @@ var _ = Describe("VPCAttachment Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("VPCAttachment Controller Address Allocation", func() {
+	Context("When reconciling resources without addresses", func() {
+		ctx := context.Background()
+
+		vpcName := "ipam-vpc"
+		vpcTypeNamespacedName := types.NamespacedName{
+			Name:      vpcName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating and reconciling the custom resource for the Kind VPC")
+			resource := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcName,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks: []string{
+						"10.2.2.0/24",
+						"2001:10:2:2::/64",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			By("cleanup the VPCAttachments and the VPC")
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "ipam"})
+		})
+
+		It("should allocate distinct addresses from the VPC networks", func() {
+			expectedAddresses := [][]string{
+				{"10.2.2.1/24", "2001:10:2:2::1/64"},
+				{"10.2.2.2/24", "2001:10:2:2::2/64"},
+			}
+
+			for i, addresses := range expectedAddresses {
+				vpcAttachmentTypeNamespacedName := types.NamespacedName{
+					Name:      fmt.Sprintf("ipam-vpcattachment-%d", i),
+					Namespace: "default",
+				}
+				By(fmt.Sprintf("creating the custom resource %s without addresses", vpcAttachmentTypeNamespacedName.Name))
+				resource := &galacticv1alpha.VPCAttachment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      vpcAttachmentTypeNamespacedName.Name,
+						Namespace: "default",
+						Labels:    map[string]string{"test": "ipam"},
+					},
+					Spec: galacticv1alpha.VPCAttachmentSpec{
+						VPC: corev1.ObjectReference{
+							APIVersion: "galactic.datumapis.com/v1alpha",
+							Kind:       "VPC",
+							Name:       vpcName,
+							Namespace:  "default",
+						},
+						Interface: galacticv1alpha.VPCAttachmentInterface{
+							Name: "galactic0",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+				vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+					Client:     k8sClient,
+					Scheme:     k8sClient.Scheme(),
+					Identifier: identifier.NewFromSeed(int64(i)),
+				}
+				_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: vpcAttachmentTypeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource = &galacticv1alpha.VPCAttachment{}
+				Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.Ready).To(BeTrue())
+				Expect(resource.Status.Addresses).To(Equal(addresses))
+				waitForCache(ctx, resource)
+			}
+
+			By("recording the identifier usage of the VPC")
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err := vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testutil.ToFloat64(vpcAttachmentIdentifiersUsed.WithLabelValues("default", vpcName))).To(Equal(2.0))
+			Expect(testutil.ToFloat64(vpcAttachmentIdentifiersAvailable.WithLabelValues("default", vpcName))).
+				To(Equal(float64(identifier.MaxVPCAttachment - 3)))
+		})
+
+		newVPCAttachment := func(name string, addresses ...string) *galacticv1alpha.VPCAttachment {
+			return &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{"test": "ipam"},
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcName,
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: addresses,
+					},
+				},
+			}
+		}
+
+		reconcileVPCAttachment := func(name string) *galacticv1alpha.VPCAttachment {
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			namespacedName := types.NamespacedName{Name: name, Namespace: "default"}
+			_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, namespacedName, resource)).To(Succeed())
+			return resource
+		}
+
+		It("should skip addresses claimed by another VPCAttachment", func() {
+			By("claiming the lowest IPv4 address for another VPCAttachment")
+			vpc := &galacticv1alpha.VPC{}
+			Expect(k8sClient.Get(ctx, vpcTypeNamespacedName, vpc)).To(Succeed())
+			claimant := newVPCAttachment("ipam-claimant")
+			claimant.UID = "ipam-claimant-uid"
+			claimed, err := claimAddress(ctx, k8sClient, vpcAddressScope(vpc.Status.Identifier), "10.2.2.1/24", claimant)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).To(BeTrue())
+			DeferCleanup(func() {
+				Expect(releaseIdentifiers(ctx, k8sClient, claimant)).To(Succeed())
+			})
+
+			By("allocating the addresses of a VPCAttachment whose cache misses the claimant")
+			Expect(k8sClient.Create(ctx, newVPCAttachment("ipam-vpcattachment"))).To(Succeed())
+			resource := reconcileVPCAttachment("ipam-vpcattachment")
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Addresses).To(Equal([]string{"10.2.2.2/24", "2001:10:2:2::1/64"}))
+
+			By("reserving the allocated addresses")
+			identifiers, err := claimedIdentifiers(ctx, k8sClient, vpcAddressScope(vpc.Status.Identifier))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(identifiers).To(ConsistOf("0a020201", "0a020202", "20010010000200020000000000000001"))
+		})
+
+		It("should allocate an address anew that an older VPCAttachment lists in its spec", func() {
+			By("creating a VPCAttachment with the lowest addresses in its spec")
+			older := newVPCAttachment("ipam-vpcattachment-static", "10.2.2.1/24")
+			Expect(k8sClient.Create(ctx, older)).To(Succeed())
+			waitForCache(ctx, older)
+
+			By("recording the same address as allocated to a later VPCAttachment")
+			resource := newVPCAttachment("ipam-vpcattachment-stale")
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			resource.Status.Addresses = []string{"10.2.2.1/24", "2001:10:2:2::1/64"}
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+
+			By("reconciling the later VPCAttachment")
+			resource = reconcileVPCAttachment(resource.Name)
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Addresses).To(Equal([]string{"10.2.2.2/24", "2001:10:2:2::1/64"}))
+			Expect(meta.FindStatusCondition(resource.Status.Conditions, galacticv1alpha.VPCAttachmentConditionConflict)).To(BeNil())
+		})
+
+		It("should allocate the address at the index within the address ranges unless it is taken", func() {
+			addressIndex := int32(3)
+			for _, name := range []string{"ipam-vpcattachment-web-3", "ipam-vpcattachment-api-3"} {
+				resource := newVPCAttachment(name)
+				resource.Spec.Interface.AddressRanges = []string{"10.2.2.192/26"}
+				resource.Spec.Interface.AddressIndex = &addressIndex
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			}
+
+			By("allocating the address at the index to the first VPCAttachment")
+			resource := reconcileVPCAttachment("ipam-vpcattachment-web-3")
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Addresses).To(Equal([]string{"10.2.2.196/24"}))
+
+			By("allocating the lowest free address of the ranges to the second VPCAttachment")
+			resource = reconcileVPCAttachment("ipam-vpcattachment-api-3")
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Addresses).To(Equal([]string{"10.2.2.193/24"}))
+		})
+
+		It("should allocate distinct addresses to VPCAttachments reconciled concurrently", func() {
+			const vpcAttachments = 5
+			for i := range vpcAttachments {
+				Expect(k8sClient.Create(ctx, newVPCAttachment(fmt.Sprintf("ipam-vpcattachment-concurrent-%d", i)))).To(Succeed())
+			}
+
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			errs := make(chan error, vpcAttachments)
+			for i := range vpcAttachments {
+				go func() {
+					_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+						NamespacedName: types.NamespacedName{Name: fmt.Sprintf("ipam-vpcattachment-concurrent-%d", i), Namespace: "default"},
+					})
+					errs <- err
+				}()
+			}
+			for range vpcAttachments {
+				Expect(<-errs).NotTo(HaveOccurred())
+			}
+
+			addresses := map[string]string{}
+			for i := range vpcAttachments {
+				resource := &galacticv1alpha.VPCAttachment{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("ipam-vpcattachment-concurrent-%d", i), Namespace: "default"}, resource)).To(Succeed())
+				Expect(resource.Status.Addresses).To(HaveLen(2))
+				for _, address := range resource.Status.Addresses {
+					Expect(addresses).NotTo(HaveKey(address), "address %s allocated twice", address)
+					addresses[address] = resource.Name
+				}
+			}
+		})
+
+		It("should map the IdentifierClaims of VPCAttachment identifiers to the VPC", func() {
+			Expect(k8sClient.Create(ctx, newVPCAttachment("ipam-vpcattachment"))).To(Succeed())
+			reconcileVPCAttachment("ipam-vpcattachment")
+
+			vpc := &galacticv1alpha.VPC{}
+			Expect(k8sClient.Get(ctx, vpcTypeNamespacedName, vpc)).To(Succeed())
+			waitForCache(ctx, vpc)
+			vpcControllerReconciler := &VPCReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			firstClaim := func(scope string) *galacticv1alpha.IdentifierClaim {
+				var claims galacticv1alpha.IdentifierClaimList
+				Expect(k8sClient.List(ctx, &claims, client.MatchingLabels{galacticv1alpha.IdentifierClaimScopeLabel: scope})).To(Succeed())
+				Expect(claims.Items).NotTo(BeEmpty())
+				return &claims.Items[0]
+			}
+			Expect(vpcControllerReconciler.vpcOfVPCAttachmentIdentifierClaim(ctx, firstClaim(vpcAttachmentIdentifierScope(vpc.Status.Identifier)))).
+				To(Equal([]reconcile.Request{{NamespacedName: vpcTypeNamespacedName}}))
+			Expect(vpcControllerReconciler.vpcOfVPCAttachmentIdentifierClaim(ctx, firstClaim(vpcAddressScope(vpc.Status.Identifier)))).
+				To(BeEmpty())
+		})
+
+		It("should map the VPC to its VPCAttachments", func() {
+			for i := range 2 {
+				resource := &galacticv1alpha.VPCAttachment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("ipam-vpcattachment-%d", i),
+						Namespace: "default",
+						Labels:    map[string]string{"test": "ipam"},
+					},
+					Spec: galacticv1alpha.VPCAttachmentSpec{
+						VPC: corev1.ObjectReference{
+							APIVersion: "galactic.datumapis.com/v1alpha",
+							Kind:       "VPC",
+							Name:       vpcName,
+							Namespace:  "default",
+						},
+						Interface: galacticv1alpha.VPCAttachmentInterface{
+							Name: "galactic0",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+				waitForCache(ctx, resource)
+			}
+
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			vpc := &galacticv1alpha.VPC{}
+			Expect(k8sClient.Get(ctx, vpcTypeNamespacedName, vpc)).To(Succeed())
+			Expect(vpcAttachmentControllerReconciler.vpcAttachmentsOfVPC(ctx, vpc)).To(ConsistOf(
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "ipam-vpcattachment-0", Namespace: "default"}},
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "ipam-vpcattachment-1", Namespace: "default"}},
+			))
+		})
+	})
+})
+
+var _ = Describe("VPCAttachment Controller Address Conflicts", func() {
+	Context("When two resources use the same address", func() {
+		ctx := context.Background()
+
+		vpcName := "conflict-vpc"
+		vpcTypeNamespacedName := types.NamespacedName{
+			Name:      vpcName,
+			Namespace: "default",
+		}
+
+		newVPCAttachment := func(name string) *galacticv1alpha.VPCAttachment {
+			return &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{"test": "conflict"},
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcName,
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.3.3.1/24"},
+					},
+				},
+			}
+		}
+
+		reconcileVPCAttachment := func(name string) {
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: name, Namespace: "default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating and reconciling the custom resource for the Kind VPC")
+			resource := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcName,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks: []string{"10.3.3.0/24"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			By("cleanup the VPCAttachments and the VPC")
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "conflict"})
+		})
+
+		It("should mark the later resource as conflicting until the address is released", func() {
+			By("creating and reconciling the first VPCAttachment")
+			first := newVPCAttachment("conflict-first")
+			Expect(k8sClient.Create(ctx, first)).To(Succeed())
+			reconcileVPCAttachment(first.Name)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(first), first)).To(Succeed())
+			Expect(first.Status.Ready).To(BeTrue())
+			waitForCache(ctx, first)
+
+			By("creating and reconciling a second VPCAttachment with the same address")
+			second := newVPCAttachment("conflict-second")
+			Expect(k8sClient.Create(ctx, second)).To(Succeed())
+			Eventually(func(g Gomega) {
+				reconcileVPCAttachment(second.Name)
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(second), second)).To(Succeed())
+				g.Expect(second.Status.Ready).To(BeFalse())
+				condition := meta.FindStatusCondition(second.Status.Conditions, galacticv1alpha.VPCAttachmentConditionConflict)
+				g.Expect(condition).NotTo(BeNil())
+				g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				g.Expect(condition.Message).To(ContainSubstring("default/conflict-first"))
+			}).Should(Succeed())
+
+			nadResource := &nadv1.NetworkAttachmentDefinition{}
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(second), nadResource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			By("deleting the first VPCAttachment and reconciling the second one again")
+			Expect(k8sClient.Delete(ctx, first)).To(Succeed())
+			reconcileVPCAttachment(first.Name)
+			Eventually(func(g Gomega) {
+				reconcileVPCAttachment(second.Name)
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(second), second)).To(Succeed())
+				g.Expect(second.Status.Ready).To(BeTrue())
+				g.Expect(meta.FindStatusCondition(second.Status.Conditions, galacticv1alpha.VPCAttachmentConditionConflict)).To(BeNil())
+			}).Should(Succeed())
+		})
+
+		It("should delete the NetworkAttachmentDefinition of a resource that starts conflicting", func() {
+			By("reconciling the first VPCAttachment")
+			first := newVPCAttachment("conflict-first")
+			Expect(k8sClient.Create(ctx, first)).To(Succeed())
+			reconcileVPCAttachment(first.Name)
+			waitForCache(ctx, first)
+
+			By("reconciling a second VPCAttachment with another address")
+			second := newVPCAttachment("conflict-second")
+			second.Spec.Interface.Addresses = []string{"10.3.3.2/24"}
+			Expect(k8sClient.Create(ctx, second)).To(Succeed())
+			reconcileVPCAttachment(second.Name)
+			nadResource := &nadv1.NetworkAttachmentDefinition{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(second), nadResource)).To(Succeed())
+
+			By("changing the address of the second VPCAttachment to the one of the first")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(second), second)).To(Succeed())
+			second.Spec.Interface.Addresses = []string{"10.3.3.1/24"}
+			Expect(k8sClient.Update(ctx, second)).To(Succeed())
+			waitForCache(ctx, second)
+			reconcileVPCAttachment(second.Name)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(second), second)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(second.Status.Conditions, galacticv1alpha.VPCAttachmentConditionConflict)).To(BeTrue())
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(second), nadResource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("VPCAttachment Controller Grants", func() {
+	Context("When a resource references a VPC in another namespace", func() {
+		ctx := context.Background()
+
+		vpcName := "granted-vpc"
+		vpcTypeNamespacedName := types.NamespacedName{
+			Name:      vpcName,
+			Namespace: "default",
+		}
+		vpcAttachmentTypeNamespacedName := types.NamespacedName{
+			Name:      "granted-vpcattachment",
+			Namespace: "grant-workloads",
+		}
+
+		reconcileVPCAttachment := func() {
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcAttachmentTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating the namespace of the VPCAttachment")
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: vpcAttachmentTypeNamespacedName.Namespace}}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
+			if errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+			}
+
+			By("creating and reconciling the custom resource for the Kind VPC")
+			resource := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcName,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks: []string{"10.5.5.0/24"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating the VPCAttachment in another namespace")
+			vpcAttachment := &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcAttachmentTypeNamespacedName.Name,
+					Namespace: vpcAttachmentTypeNamespacedName.Namespace,
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcName,
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.5.5.1/24"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("cleanup the VPCAttachmentGrant, the VPCAttachment and the VPC")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &galacticv1alpha.VPCAttachmentGrant{ObjectMeta: metav1.ObjectMeta{
+				Name:      "granted-vpcattachmentgrant",
+				Namespace: "default",
+			}}))).To(Succeed())
+
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, vpcAttachment)).To(Succeed())
+			reconcileVPCAttachment()
+			waitForCache(ctx, vpcAttachment)
+
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "grant"})
+		})
+
+		It("should only connect the resource once a VPCAttachmentGrant permits it", func() {
+			By("reconciling the VPCAttachment without a VPCAttachmentGrant")
+			reconcileVPCAttachment()
+
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			Expect(vpcAttachment.Status.Ready).To(BeFalse())
+			Expect(vpcAttachment.Status.Reason).To(Equal(galacticv1alpha.VPCAttachmentReasonNotPermitted))
+			condition := meta.FindStatusCondition(vpcAttachment.Status.Conditions, galacticv1alpha.VPCAttachmentConditionVPCResolved)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(galacticv1alpha.VPCAttachmentReasonNotPermitted))
+
+			nadResource := &nadv1.NetworkAttachmentDefinition{}
+			err := k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, nadResource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			By("creating a VPCAttachmentGrant in the namespace of the VPC")
+			vpcAttachmentGrant := &galacticv1alpha.VPCAttachmentGrant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "granted-vpcattachmentgrant",
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCAttachmentGrantSpec{
+					From: []galacticv1alpha.VPCAttachmentGrantFrom{{Namespace: vpcAttachmentTypeNamespacedName.Namespace}},
+					To:   []galacticv1alpha.VPCAttachmentGrantTo{{Name: vpcName}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachmentGrant)).To(Succeed())
+
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{Client: k8sClient}
+			Expect(vpcAttachmentControllerReconciler.vpcAttachmentsOfGrant(ctx, vpcAttachmentGrant)).To(ConsistOf(
+				reconcile.Request{NamespacedName: vpcAttachmentTypeNamespacedName},
+			))
+
+			By("reconciling the VPCAttachment again")
+			reconcileVPCAttachment()
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			Expect(vpcAttachment.Status.Ready).To(BeTrue())
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, nadResource)).To(Succeed())
+
+			By("revoking the VPCAttachmentGrant")
+			Expect(k8sClient.Delete(ctx, vpcAttachmentGrant)).To(Succeed())
+			reconcileVPCAttachment()
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			Expect(vpcAttachment.Status.Ready).To(BeFalse())
+			Expect(vpcAttachment.Status.Reason).To(Equal(galacticv1alpha.VPCAttachmentReasonNotPermitted))
+			err = k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, nadResource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("VPCAttachment Controller Exclusive Binding", func() {
+	Context("When several pods reference an Exclusive resource", func() {
+		ctx := context.Background()
+
+		vpcName := "binding-vpc"
+		vpcTypeNamespacedName := types.NamespacedName{
+			Name:      vpcName,
+			Namespace: "default",
+		}
+		vpcAttachmentTypeNamespacedName := types.NamespacedName{
+			Name:      "binding-vpcattachment",
+			Namespace: "default",
+		}
+
+		newPod := func(name string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{"test": "binding"},
+					Annotations: map[string]string{
+						galacticv1alpha.VPCAttachmentAnnotation: vpcAttachmentTypeNamespacedName.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "test-container",
+							Image: "test:latest",
+						},
+					},
+				},
+			}
+		}
+
+		reconcileVPCAttachment := func() *galacticv1alpha.VPCAttachment {
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcAttachmentTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			return vpcAttachment
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating and reconciling the custom resource for the Kind VPC")
+			resource := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcName,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks: []string{"10.6.6.0/24"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating the Exclusive VPCAttachment")
+			vpcAttachment := &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcAttachmentTypeNamespacedName.Name,
+					Namespace: "default",
+					Labels:    map[string]string{"test": "binding"},
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcName,
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.6.6.1/24"},
+					},
+					BindingMode: galacticv1alpha.VPCAttachmentBindingModeExclusive,
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("cleanup the pods, the VPCAttachment and the VPC")
+			Expect(k8sClient.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace("default"),
+				client.MatchingLabels{"test": "binding"}, client.GracePeriodSeconds(0))).To(Succeed())
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "binding"})
+		})
+
+		It("should bind the oldest active pod until it is gone", func() {
+			By("reconciling without pods")
+			vpcAttachment := reconcileVPCAttachment()
+			Expect(vpcAttachment.Status.Ready).To(BeTrue())
+			Expect(vpcAttachment.Status.BoundPod).To(BeEmpty())
+
+			By("creating two pods referencing the VPCAttachment")
+			first := newPod("binding-first")
+			Expect(k8sClient.Create(ctx, first)).To(Succeed())
+			second := newPod("binding-second")
+			Expect(k8sClient.Create(ctx, second)).To(Succeed())
+			Expect(reconcileVPCAttachment().Status.BoundPod).To(Equal(first.Name))
+
+			By("marking the first pod as failed")
+			first.Status.Phase = corev1.PodFailed
+			Expect(k8sClient.Status().Update(ctx, first)).To(Succeed())
+			Expect(reconcileVPCAttachment().Status.BoundPod).To(Equal(second.Name))
+
+			By("deleting the second pod")
+			Expect(k8sClient.Delete(ctx, second, client.GracePeriodSeconds(0))).To(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(second), second))).To(BeTrue())
+			}).Should(Succeed())
+			Expect(reconcileVPCAttachment().Status.BoundPod).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("VPCAttachment Controller Stamped VPCAttachments", func() {
+	Context("When reconciling a resource stamped out for a pod", func() {
+		ctx := context.Background()
+
+		vpcName := "stamped-vpc"
+		vpcTypeNamespacedName := types.NamespacedName{
+			Name:      vpcName,
+			Namespace: "default",
+		}
+		vpcAttachmentTypeNamespacedName := types.NamespacedName{
+			Name:      "stamped-pod-template",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating and reconciling the custom resource for the Kind VPC")
+			resource := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcName,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks: []string{"10.7.7.0/24"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating the VPCAttachment stamped out for the pod")
+			vpcAttachment := &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcAttachmentTypeNamespacedName.Name,
+					Namespace: "default",
+					Labels: map[string]string{
+						"test":                                "stamped",
+						galacticv1alpha.VPCAttachmentPodLabel: "stamped-pod",
+						galacticv1alpha.VPCAttachmentTemplateLabel: "template",
+					},
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcName,
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.7.7.1/24"},
+					},
+					BindingMode: galacticv1alpha.VPCAttachmentBindingModeExclusive,
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("cleanup the pod, the VPCAttachment and the VPC")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:      "stamped-pod",
+				Namespace: "default",
+			}}, client.GracePeriodSeconds(0)))).To(Succeed())
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "stamped"})
+		})
+
+		It("should wait for the pod and become owned by it", func() {
+			vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+
+			By("reconciling before the pod exists")
+			result, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcAttachmentTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", StampedVPCAttachmentGracePeriod))
+
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			Expect(vpcAttachment.DeletionTimestamp).To(BeNil())
+			Expect(vpcAttachment.OwnerReferences).To(BeEmpty())
+
+			By("creating the pod and reconciling again")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "stamped-pod",
+					Namespace: "default",
+					Annotations: map[string]string{
+						galacticv1alpha.VPCAttachmentAnnotation: vpcAttachmentTypeNamespacedName.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "test-container",
+							Image: "test:latest",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			_, err = vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcAttachmentTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+			Expect(vpcAttachment.OwnerReferences).To(HaveLen(1))
+			Expect(vpcAttachment.OwnerReferences[0].Kind).To(Equal("Pod"))
+			Expect(vpcAttachment.OwnerReferences[0].UID).To(Equal(pod.UID))
+			Expect(vpcAttachment.Status.BoundPod).To(Equal(pod.Name))
+		})
+	})
+})
+
+// cleanupVPC deletes the VPCAttachments matching the labels together with the
+// VPC and reconciles them so their finalizers are removed.
+var _ = Describe("VPCAttachment Controller Security Groups", func() {
+	Context("When a resource references VPCSecurityGroups", func() {
+		ctx := context.Background()
+
+		vpcName := "sg-vpc"
+		vpcTypeNamespacedName := types.NamespacedName{
+			Name:      vpcName,
+			Namespace: "default",
+		}
+
+		newVPCAttachment := func(name string, address string, labels map[string]string, securityGroups ...string) *galacticv1alpha.VPCAttachment {
+			objectLabels := map[string]string{"test": "securitygroup"}
+			for key, value := range labels {
+				objectLabels[key] = value
+			}
+			return &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    objectLabels,
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcName,
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{address},
+					},
+					SecurityGroups: securityGroups,
+				},
+			}
+		}
+
+		newVPCSecurityGroup := func(name string) *galacticv1alpha.VPCSecurityGroup {
+			return &galacticv1alpha.VPCSecurityGroup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSecurityGroupSpec{
+					Ingress: []galacticv1alpha.VPCSecurityGroupRule{
+						{
+							Peers: []galacticv1alpha.VPCSecurityGroupPeer{
+								{VPCAttachmentSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db"}}},
+							},
+							Protocol: galacticv1alpha.VPCSecurityGroupProtocolTCP,
+							Ports:    []galacticv1alpha.VPCSecurityGroupPort{{Port: 5432}},
+						},
+					},
+				},
+			}
+		}
+
+		vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+			Client:     k8sClient,
+			Scheme:     k8sClient.Scheme(),
+			Identifier: identifier.NewFromSeed(424242),
+		}
+
+		reconcileVPCAttachment := func(name string) {
+			_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: name, Namespace: "default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating and reconciling the custom resource for the Kind VPC")
+			resource := &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcName,
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks: []string{"10.8.8.0/24"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			By("cleanup the VPCSecurityGroups, the VPCAttachments and the VPC")
+			Expect(k8sClient.DeleteAllOf(ctx, &galacticv1alpha.VPCSecurityGroup{}, client.InNamespace("default"))).To(Succeed())
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "securitygroup"})
+		})
+
+		It("should render the addresses of the selected VPCAttachments into the firewall", func() {
+			By("creating the VPCSecurityGroup and the selected VPCAttachment")
+			Expect(k8sClient.Create(ctx, newVPCSecurityGroup("sg-app"))).To(Succeed())
+			db := newVPCAttachment("sg-db", "10.8.8.2/24", map[string]string{"role": "db"})
+			Expect(k8sClient.Create(ctx, db)).To(Succeed())
+
+			By("reconciling a VPCAttachment referencing the VPCSecurityGroup")
+			app := newVPCAttachment("sg-app", "10.8.8.1/24", nil, "sg-app")
+			Expect(k8sClient.Create(ctx, app)).To(Succeed())
+			reconcileVPCAttachment(app.Name)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+			Expect(app.Status.Ready).To(BeTrue())
+
+			nadResource := &nadv1.NetworkAttachmentDefinition{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(app), nadResource)).To(Succeed())
+			var config struct {
+				Plugins []struct {
+					Firewall *cniconfig.Firewall `json:"firewall"`
+				} `json:"plugins"`
+			}
+			Expect(json.Unmarshal([]byte(nadResource.Spec.Config), &config)).To(Succeed())
+			Expect(config.Plugins).NotTo(BeEmpty())
+			Expect(config.Plugins[0].Firewall).NotTo(BeNil())
+			Expect(config.Plugins[0].Firewall.Ingress).To(Equal([]cniconfig.FirewallRule{{
+				Networks: []string{"10.8.8.2/32"},
+				Protocol: "tcp",
+				Ports:    []string{"5432"},
+			}}))
+			Expect(config.Plugins[0].Firewall.Egress).To(BeEmpty())
+		})
+
+		It("should delete the NetworkAttachmentDefinition while a VPCSecurityGroup is missing", func() {
+			By("reconciling a VPCAttachment referencing an existing VPCSecurityGroup")
+			securityGroup := newVPCSecurityGroup("sg-app")
+			Expect(k8sClient.Create(ctx, securityGroup)).To(Succeed())
+			app := newVPCAttachment("sg-app", "10.8.8.1/24", nil, "sg-app")
+			Expect(k8sClient.Create(ctx, app)).To(Succeed())
+			reconcileVPCAttachment(app.Name)
+			nadResource := &nadv1.NetworkAttachmentDefinition{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(app), nadResource)).To(Succeed())
+
+			By("deleting the VPCSecurityGroup and reconciling again")
+			Expect(k8sClient.Delete(ctx, securityGroup)).To(Succeed())
+			reconcileVPCAttachment(app.Name)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+			Expect(app.Status.Ready).To(BeFalse())
+			condition := meta.FindStatusCondition(app.Status.Conditions, galacticv1alpha.VPCAttachmentConditionNetworkAttachmentDefinitionSynced)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(galacticv1alpha.VPCAttachmentReasonSecurityGroupNotFound))
+			Expect(condition.Message).To(ContainSubstring("sg-app"))
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(app), nadResource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should map a VPCAttachment to the VPCAttachments whose VPCSecurityGroups select it", func() {
+			Expect(k8sClient.Create(ctx, newVPCSecurityGroup("sg-app"))).To(Succeed())
+			app := newVPCAttachment("sg-app", "10.8.8.1/24", nil, "sg-app")
+			Expect(k8sClient.Create(ctx, app)).To(Succeed())
+
+			db := newVPCAttachment("sg-db", "10.8.8.2/24", map[string]string{"role": "db"})
+			Expect(vpcAttachmentControllerReconciler.vpcAttachmentsSelecting(ctx, db)).To(ConsistOf(
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)},
+			))
+
+			web := newVPCAttachment("sg-web", "10.8.8.3/24", map[string]string{"role": "web"})
+			Expect(vpcAttachmentControllerReconciler.vpcAttachmentsSelecting(ctx, web)).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("VPCAttachment Controller TransitGateway Namespaces", func() {
+	Context("When the route table selects the namespace of the VPCs by label", func() {
+		ctx := context.Background()
+
+		namespaceName := "tgw-workloads"
+		vpcTypeNamespacedName := types.NamespacedName{Name: "tgw-vpc", Namespace: namespaceName}
+		peerVPCTypeNamespacedName := types.NamespacedName{Name: "tgw-peer-vpc", Namespace: namespaceName}
+		vpcAttachmentTypeNamespacedName := types.NamespacedName{Name: "tgw-vpcattachment", Namespace: namespaceName}
+		transitGatewayName := "namespace-hub"
+
+		vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+			Client:     k8sClient,
+			Scheme:     k8sClient.Scheme(),
+			Identifier: identifier.NewFromSeed(424242),
+		}
+
+		reconcileVPCAttachment := func() {
+			_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: vpcAttachmentTypeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		setNamespaceLabel := func(value string) *corev1.Namespace {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			if value == "" {
+				delete(namespace.Labels, "galactic-test-transitgateway")
+			} else {
+				if namespace.Labels == nil {
+					namespace.Labels = map[string]string{}
+				}
+				namespace.Labels["galactic-test-transitgateway"] = value
+			}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+			return namespace
+		}
+
+		BeforeEach(func() {
+			err := nadv1.AddToScheme(k8sClient.Scheme())
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating the namespace of the VPCs")
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
+			if errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+			}
+			setNamespaceLabel("enabled")
+
+			By("creating a TransitGateway whose route table selects the namespace")
+			transitGateway := &galacticv1alpha.TransitGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: transitGatewayName},
+				Spec: galacticv1alpha.TransitGatewaySpec{
+					RouteTables: []galacticv1alpha.TransitGatewayRouteTable{{
+						Name:          "all",
+						PropagateFrom: []string{"all"},
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"galactic-test-transitgateway": "enabled"},
+						},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, transitGateway)).To(Succeed())
+
+			By("creating and reconciling two VPCs associated with the TransitGateway")
+			vpcControllerReconciler := &VPCReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Identifier: identifier.NewFromSeed(424242),
+			}
+			for nn, network := range map[types.NamespacedName]string{
+				vpcTypeNamespacedName:     "10.9.1.0/24",
+				peerVPCTypeNamespacedName: "10.9.2.0/24",
+			} {
+				vpc := &galacticv1alpha.VPC{
+					ObjectMeta: metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace},
+					Spec: galacticv1alpha.VPCSpec{
+						Networks: []string{network},
+						TransitGateway: &galacticv1alpha.VPCTransitGatewayAssociation{
+							Name:       transitGatewayName,
+							RouteTable: "all",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+				_, err = vpcControllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("creating the VPCAttachment of the first VPC")
+			vpcAttachment := &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      vpcAttachmentTypeNamespacedName.Name,
+					Namespace: namespaceName,
+					Labels:    map[string]string{"test": "transitgateway"},
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       vpcTypeNamespacedName.Name,
+						Namespace:  namespaceName,
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name:      "galactic0",
+						Addresses: []string{"10.9.1.1/24"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+			waitForCache(ctx, vpcAttachment)
+		})
+
+		AfterEach(func() {
+			By("cleanup the VPCAttachment, the VPCs and the TransitGateway")
+			cleanupVPC(ctx, vpcTypeNamespacedName, client.MatchingLabels{"test": "transitgateway"})
+			cleanupVPC(ctx, peerVPCTypeNamespacedName, client.MatchingLabels{"test": "transitgateway"})
+			Expect(k8sClient.Delete(ctx, &galacticv1alpha.TransitGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: transitGatewayName},
+			})).To(Succeed())
+			setNamespaceLabel("")
+		})
+
+		It("should add and remove the routes through the TransitGateway when the namespace is relabelled", func() {
+			expectPeerRoute := func(present bool) {
+				vpcAttachment := &galacticv1alpha.VPCAttachment{}
+				Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, vpcAttachment)).To(Succeed())
+				Expect(vpcAttachment.Status.Ready).To(BeTrue())
+				nadResource := &nadv1.NetworkAttachmentDefinition{}
+				Expect(k8sClient.Get(ctx, vpcAttachmentTypeNamespacedName, nadResource)).To(Succeed())
+				if present {
+					Expect(vpcAttachment.Status.EffectiveRoutes).To(ContainElement(HaveField("Destination", "10.9.2.0/24")))
+					Expect(nadResource.Spec.Config).To(ContainSubstring("10.9.2.0/24"))
+				} else {
+					Expect(vpcAttachment.Status.EffectiveRoutes).To(BeEmpty())
+					Expect(nadResource.Spec.Config).NotTo(ContainSubstring("10.9.2.0/24"))
+				}
+			}
+
+			By("reconciling the VPCAttachment while the route table selects the namespace")
+			reconcileVPCAttachment()
+			expectPeerRoute(true)
+
+			By("removing the label from the namespace")
+			namespace := setNamespaceLabel("")
+			Expect(vpcAttachmentControllerReconciler.vpcAttachmentsOfNamespace(ctx, namespace)).To(ContainElement(
+				reconcile.Request{NamespacedName: vpcAttachmentTypeNamespacedName},
+			))
+			reconcileVPCAttachment()
+			expectPeerRoute(false)
+
+			By("labelling the namespace again")
+			namespace = setNamespaceLabel("enabled")
+			Expect(vpcAttachmentControllerReconciler.vpcAttachmentsOfNamespace(ctx, namespace)).To(ContainElement(
+				reconcile.Request{NamespacedName: vpcAttachmentTypeNamespacedName},
+			))
+			reconcileVPCAttachment()
+			expectPeerRoute(true)
+		})
+	})
+})
+
+func cleanupVPC(ctx context.Context, vpcNamespacedName types.NamespacedName, labels client.MatchingLabels) {
+	Expect(k8sClient.DeleteAllOf(ctx, &galacticv1alpha.VPCAttachment{},
+		client.InNamespace(vpcNamespacedName.Namespace), labels)).To(Succeed())
+
+	vpcAttachmentControllerReconciler := &VPCAttachmentReconciler{
+		Client:     k8sClient,
+		Scheme:     k8sClient.Scheme(),
+		Identifier: identifier.NewFromSeed(424242),
+	}
+	var vpcAttachments galacticv1alpha.VPCAttachmentList
+	Expect(k8sClient.List(ctx, &vpcAttachments, client.InNamespace(vpcNamespacedName.Namespace), labels)).To(Succeed())
+	for _, vpcAttachment := range vpcAttachments.Items {
+		_, err := vpcAttachmentControllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: client.ObjectKeyFromObject(&vpcAttachment),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		waitForCache(ctx, &vpcAttachment)
+	}
+
+	resource := &galacticv1alpha.VPC{}
+	Expect(k8sClient.Get(ctx, vpcNamespacedName, resource)).To(Succeed())
+	Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+	vpcControllerReconciler := &VPCReconciler{
+		Client:     k8sClient,
+		Scheme:     k8sClient.Scheme(),
+		Identifier: identifier.NewFromSeed(424242),
+	}
+	Eventually(func(g Gomega) {
+		_, err := vpcControllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: vpcNamespacedName})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(errors.IsNotFound(k8sClient.Get(ctx, vpcNamespacedName, resource))).To(BeTrue())
+	}).Should(Succeed())
+}
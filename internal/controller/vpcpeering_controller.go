@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/peering"
+)
+
+// VPCPeeringReconciler reports whether VPCPeerings connect their VPCs. The
+// routes between the VPCs are rendered by the VPCAttachmentReconciler.
+type VPCPeeringReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcpeerings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcpeerings/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcs,verbs=get;list;watch
+
+func (r *VPCPeeringReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var vpcPeering galacticv1alpha.VPCPeering
+	if err := r.Get(ctx, req.NamespacedName, &vpcPeering); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !vpcPeering.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	state, err := peering.Evaluate(ctx, r.Client, &vpcPeering)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	changed := false
+	if state.Accepted {
+		changed = setCondition(&vpcPeering.Status.Conditions, vpcPeering.Generation, galacticv1alpha.VPCPeeringConditionAccepted,
+			metav1.ConditionTrue, galacticv1alpha.VPCPeeringReasonAccepted,
+			fmt.Sprintf("VPC %s peers with VPC %s", peering.PeerVPC(&vpcPeering), peering.VPC(&vpcPeering)))
+	} else {
+		changed = setCondition(&vpcPeering.Status.Conditions, vpcPeering.Generation, galacticv1alpha.VPCPeeringConditionAccepted,
+			metav1.ConditionFalse, state.Reason, state.Message)
+	}
+	if state.Peer != nil {
+		changed = setVPCPeeringReady(&vpcPeering, metav1.ConditionTrue, galacticv1alpha.ReasonReady, "VPCs are connected") || changed
+	} else {
+		changed = setVPCPeeringReady(&vpcPeering, metav1.ConditionFalse, state.Reason, state.Message) || changed
+	}
+
+	if changed {
+		return ctrl.Result{}, r.Status().Update(ctx, &vpcPeering)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *VPCPeeringReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&galacticv1alpha.VPCPeering{}).
+		Watches(&galacticv1alpha.VPCPeering{}, handler.EnqueueRequestsFromMapFunc(r.reciprocalVPCPeerings)).
+		Watches(&galacticv1alpha.VPCPeering{}, handler.EnqueueRequestsFromMapFunc(r.siblingVPCPeerings)).
+		Watches(&galacticv1alpha.VPC{}, handler.EnqueueRequestsFromMapFunc(r.vpcPeeringsOfVPC)).
+		Named("vpcpeering").
+		Complete(r)
+}
+
+// reciprocalVPCPeerings maps a VPCPeering to the VPCPeerings in the namespace
+// of its peer VPC, whose acceptance depends on it.
+func (r *VPCPeeringReconciler) reciprocalVPCPeerings(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpcPeering, ok := obj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil
+	}
+	vpc, peerVPC := peering.VPC(vpcPeering), peering.PeerVPC(vpcPeering)
+	var vpcPeerings galacticv1alpha.VPCPeeringList
+	if err := r.List(ctx, &vpcPeerings, client.InNamespace(peerVPC.Namespace)); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list reciprocal VPCPeerings", "vpcPeering", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range vpcPeerings.Items {
+		other := &vpcPeerings.Items[i]
+		if peering.VPC(other) == peerVPC && peering.PeerVPC(other) == vpc {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+		}
+	}
+	return requests
+}
+
+// siblingVPCPeerings maps a VPCPeering to the other VPCPeerings of its VPC,
+// whose peer VPCs must not overlap with its peer VPC.
+func (r *VPCPeeringReconciler) siblingVPCPeerings(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpcPeering, ok := obj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil
+	}
+	vpc := peering.VPC(vpcPeering)
+	var vpcPeerings galacticv1alpha.VPCPeeringList
+	if err := r.List(ctx, &vpcPeerings, client.InNamespace(vpc.Namespace)); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list sibling VPCPeerings", "vpcPeering", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range vpcPeerings.Items {
+		other := &vpcPeerings.Items[i]
+		if other.Name != vpcPeering.Name && peering.VPC(other) == vpc {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+		}
+	}
+	return requests
+}
+
+// vpcPeeringsOfVPC maps a VPC to the VPCPeerings connecting it, on either
+// side of the peering.
+func (r *VPCPeeringReconciler) vpcPeeringsOfVPC(ctx context.Context, obj client.Object) []reconcile.Request {
+	vpc := client.ObjectKeyFromObject(obj)
+	var vpcPeerings galacticv1alpha.VPCPeeringList
+	if err := r.List(ctx, &vpcPeerings); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to list VPCPeerings of VPC", "vpc", vpc)
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range vpcPeerings.Items {
+		vpcPeering := &vpcPeerings.Items[i]
+		if peering.VPC(vpcPeering) == vpc || peering.PeerVPC(vpcPeering) == vpc {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: vpcPeering.Namespace,
+				Name:      vpcPeering.Name,
+			}})
+		}
+	}
+	return requests
+}
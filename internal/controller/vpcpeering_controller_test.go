@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("VPCPeering Controller", func() {
+	Context("When reconciling a VPCPeering", func() {
+		ctx := context.Background()
+
+		vpcs := []*galacticv1alpha.VPC{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "peered-vpc-a", Namespace: "default"},
+				Spec:       galacticv1alpha.VPCSpec{Networks: []string{"10.21.0.0/16"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "peered-vpc-b", Namespace: "default"},
+				Spec:       galacticv1alpha.VPCSpec{Networks: []string{"10.22.0.0/16"}},
+			},
+		}
+		vpcPeering := func(name, vpcName, peerName string) *galacticv1alpha.VPCPeering {
+			return &galacticv1alpha.VPCPeering{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: galacticv1alpha.VPCPeeringSpec{
+					VPC:     vpcName,
+					PeerVPC: galacticv1alpha.VPCPeeringPeer{Name: peerName},
+				},
+			}
+		}
+		vpcPeerings := []*galacticv1alpha.VPCPeering{
+			vpcPeering("a-to-b", "peered-vpc-a", "peered-vpc-b"),
+			vpcPeering("b-to-a", "peered-vpc-b", "peered-vpc-a"),
+		}
+
+		BeforeEach(func() {
+			By("creating two ready VPCs")
+			for i, vpc := range vpcs {
+				resource := vpc.DeepCopy()
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+				resource.Status.Ready = true
+				resource.Status.Identifier = []string{"f5b6726c782a", "f5b6726c782c"}[i]
+				Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			for _, vpcPeering := range vpcPeerings {
+				Expect(k8sClient.Delete(ctx, vpcPeering.DeepCopy())).To(Succeed())
+			}
+			for _, vpc := range vpcs {
+				Expect(k8sClient.Delete(ctx, vpc.DeepCopy())).To(Succeed())
+			}
+		})
+
+		reconcileVPCPeering := func(name string) *galacticv1alpha.VPCPeering {
+			controllerReconciler := &VPCPeeringReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			namespacedName := types.NamespacedName{Namespace: "default", Name: name}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &galacticv1alpha.VPCPeering{}
+			Expect(k8sClient.Get(ctx, namespacedName, resource)).To(Succeed())
+			return resource
+		}
+
+		It("should connect the VPCs once both sides requested the peering", func() {
+			By("requesting the peering from one side")
+			Expect(k8sClient.Create(ctx, vpcPeerings[0].DeepCopy())).To(Succeed())
+			resource := reconcileVPCPeering("a-to-b")
+			Expect(resource.Status.Ready).To(BeFalse())
+			Expect(resource.Status.Reason).To(Equal(galacticv1alpha.VPCPeeringReasonPendingAcceptance))
+			Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, galacticv1alpha.VPCPeeringConditionAccepted)).To(BeTrue())
+
+			By("accepting the peering from the other side")
+			Expect(k8sClient.Create(ctx, vpcPeerings[1].DeepCopy())).To(Succeed())
+			for _, name := range []string{"a-to-b", "b-to-a"} {
+				resource = reconcileVPCPeering(name)
+				Expect(resource.Status.Ready).To(BeTrue())
+				Expect(resource.Status.Reason).To(Equal(galacticv1alpha.ReasonReady))
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, galacticv1alpha.VPCPeeringConditionAccepted)).To(BeTrue())
+			}
+		})
+	})
+})
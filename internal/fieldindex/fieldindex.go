@@ -0,0 +1,120 @@
+// Package fieldindex defines the field indexes of the VPC and VPCAttachment
+// caches shared by the controllers and the webhooks.
+package fieldindex
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+)
+
+// VPCAttachmentVPC indexes VPCAttachments by the VPC they belong to, see
+// VPCKey.
+const VPCAttachmentVPC = "spec.vpc"
+
+// VPCIdentifier indexes VPCs by their requested identifier, see
+// IdentifierKey.
+const VPCIdentifier = "spec.identifier"
+
+// VPCAssignedIdentifier indexes VPCs by the identifier assigned to them.
+const VPCAssignedIdentifier = "status.identifier"
+
+// VPCAttachmentIdentifier indexes VPCAttachments by their requested
+// identifier, see IdentifierKey.
+const VPCAttachmentIdentifier = "spec.identifier"
+
+// VPCAttachmentAddress indexes VPCAttachments by the VPC they belong to
+// combined with each of their interface addresses, see VPCAddressKey.
+const VPCAttachmentAddress = "vpcAttachment.vpcAddress"
+
+// Setup registers the field indexes the reconcilers and webhooks rely on. It must be
+// called once per manager before the reconcilers are started.
+func Setup(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &galacticv1alpha.VPC{}, VPCIdentifier, vpcIdentifierIndexer); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &galacticv1alpha.VPC{}, VPCAssignedIdentifier, vpcAssignedIdentifierIndexer); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &galacticv1alpha.VPCAttachment{}, VPCAttachmentVPC, vpcAttachmentVPCIndexer); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &galacticv1alpha.VPCAttachment{}, VPCAttachmentIdentifier, vpcAttachmentIdentifierIndexer); err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &galacticv1alpha.VPCAttachment{}, VPCAttachmentAddress, VPCAttachmentAddressKeys)
+}
+
+func vpcIdentifierIndexer(obj client.Object) []string {
+	vpc, ok := obj.(*galacticv1alpha.VPC)
+	if !ok || vpc.Spec.Identifier == "" {
+		return nil
+	}
+	return []string{IdentifierKey(vpc.Spec.Identifier)}
+}
+
+func vpcAssignedIdentifierIndexer(obj client.Object) []string {
+	vpc, ok := obj.(*galacticv1alpha.VPC)
+	if !ok || vpc.Status.Identifier == "" {
+		return nil
+	}
+	return []string{vpc.Status.Identifier}
+}
+
+func vpcAttachmentIdentifierIndexer(obj client.Object) []string {
+	vpcAttachment, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok || vpcAttachment.Spec.Identifier == "" {
+		return nil
+	}
+	return []string{IdentifierKey(vpcAttachment.Spec.Identifier)}
+}
+
+func vpcAttachmentVPCIndexer(obj client.Object) []string {
+	vpcAttachment, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil
+	}
+	return []string{VPCKey(vpcAttachment.Spec.VPC.Namespace, vpcAttachment.Spec.VPC.Name)}
+}
+
+// VPCAttachmentAddressKeys returns the keys of a VPCAttachment in the
+// VPCAttachmentAddress index.
+func VPCAttachmentAddressKeys(obj client.Object) []string {
+	vpcAttachment, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil
+	}
+	addresses := cniconfig.InterfaceAddresses(*vpcAttachment)
+	keys := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, VPCAddressKey(vpcAttachment.Spec.VPC.Namespace, vpcAttachment.Spec.VPC.Name, ip))
+	}
+	return keys
+}
+
+// IdentifierKey normalizes a hexadecimal identifier so that requested and
+// canonical representations of the same value match.
+func IdentifierKey(identifier string) string {
+	return strings.TrimLeft(strings.ToLower(identifier), "0")
+}
+
+// VPCKey returns the key of a VPC in the VPCAttachmentVPC index.
+func VPCKey(vpcNamespace, vpcName string) string {
+	return vpcNamespace + "/" + vpcName
+}
+
+// VPCAddressKey returns the key of an address of the VPCAttachments of a VPC
+// in the VPCAttachmentAddress.
+func VPCAddressKey(vpcNamespace, vpcName string, ip net.IP) string {
+	return VPCKey(vpcNamespace, vpcName) + "/" + ip.String()
+}
@@ -0,0 +1,51 @@
+package grant
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+// Allows reports whether the grant allows VPCAttachments in namespace to
+// reference the VPC vpcName in the namespace of the grant.
+func Allows(grant galacticv1alpha.VPCAttachmentGrant, namespace, vpcName string) bool {
+	fromMatches := false
+	for _, from := range grant.Spec.From {
+		if from.Namespace == namespace {
+			fromMatches = true
+			break
+		}
+	}
+	if !fromMatches {
+		return false
+	}
+	for _, to := range grant.Spec.To {
+		if to.Name == "" || to.Name == vpcName {
+			return true
+		}
+	}
+	return false
+}
+
+// VPCAttachmentPermitted reports whether a VPCAttachment in namespace may
+// reference vpc. References within the same namespace are always permitted,
+// others need a VPCAttachmentGrant in the namespace of the VPC.
+func VPCAttachmentPermitted(ctx context.Context, c client.Reader, namespace string, vpc corev1.ObjectReference) (bool, error) {
+	if vpc.Namespace == "" || vpc.Namespace == namespace {
+		return true, nil
+	}
+
+	var grants galacticv1alpha.VPCAttachmentGrantList
+	if err := c.List(ctx, &grants, client.InNamespace(vpc.Namespace)); err != nil {
+		return false, err
+	}
+	for _, grant := range grants.Items {
+		if Allows(grant, namespace, vpc.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,48 @@
+package grant_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/grant"
+)
+
+func TestAllows(t *testing.T) {
+	vpcGrant := func(from []string, to []string) galacticv1alpha.VPCAttachmentGrant {
+		g := galacticv1alpha.VPCAttachmentGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "network"},
+		}
+		for _, namespace := range from {
+			g.Spec.From = append(g.Spec.From, galacticv1alpha.VPCAttachmentGrantFrom{Namespace: namespace})
+		}
+		for _, name := range to {
+			g.Spec.To = append(g.Spec.To, galacticv1alpha.VPCAttachmentGrantTo{Name: name})
+		}
+		return g
+	}
+
+	tests := []struct {
+		name      string
+		grant     galacticv1alpha.VPCAttachmentGrant
+		namespace string
+		vpcName   string
+		wantBool  bool
+	}{
+		{"NamedVPC", vpcGrant([]string{"app"}, []string{"vpc-a"}), "app", "vpc-a", true},
+		{"AllVPCs", vpcGrant([]string{"app"}, []string{""}), "app", "vpc-b", true},
+		{"OneOfSeveralNamespaces", vpcGrant([]string{"other", "app"}, []string{"vpc-a"}), "app", "vpc-a", true},
+		{"OtherVPC", vpcGrant([]string{"app"}, []string{"vpc-a"}), "app", "vpc-b", false},
+		{"OtherNamespace", vpcGrant([]string{"other"}, []string{"vpc-a"}), "app", "vpc-a", false},
+		{"NoTo", vpcGrant([]string{"app"}, nil), "app", "vpc-a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grant.Allows(tt.grant, tt.namespace, tt.vpcName); got != tt.wantBool {
+				t.Errorf("Allows() got = %v, want = %v", got, tt.wantBool)
+			}
+		})
+	}
+}
@@ -1,14 +1,19 @@
 package identifier
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"time"
 )
 
 const MaxVPC uint64 = 0xFFFFFFFFFFFF
 const MaxVPCAttachment uint64 = 0xFFFF
 
+// ErrSpaceExhausted is returned when every usable identifier is in use.
+var ErrSpaceExhausted = errors.New("identifier space exhausted")
+
 type Identifier struct {
 	r *rand.Rand
 }
@@ -36,6 +41,40 @@ func (id *Identifier) FromValue(value uint64, max uint64) (string, error) {
 	return fmt.Sprintf("%0*x", maxLen, value), nil
 }
 
+// FromString parses a hexadecimal identifier and returns it in its canonical
+// representation, see FromValue.
+func (id *Identifier) FromString(value string, max uint64) (string, error) {
+	n, err := strconv.ParseUint(value, 16, 64)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a hexadecimal value", value)
+	}
+	return id.FromValue(n, max)
+}
+
+// LowestFree returns the lowest identifier that is not in used. Identifiers
+// in used which cannot be parsed are ignored. It returns ErrSpaceExhausted if
+// all identifiers up to max are in use.
+func (id *Identifier) LowestFree(used []string, max uint64) (string, error) {
+	values := make(map[uint64]struct{}, len(used))
+	for _, identifier := range used {
+		if n, err := strconv.ParseUint(identifier, 16, 64); err == nil {
+			values[n] = struct{}{}
+		}
+	}
+	for n := uint64(1); n < max; n++ {
+		if _, exists := values[n]; !exists {
+			return id.FromValue(n, max)
+		}
+	}
+	return "", ErrSpaceExhausted
+}
+
+// Usable returns the number of identifiers up to max that can be assigned,
+// excluding the special values.
+func Usable(max uint64) uint64 {
+	return max - 1
+}
+
 func (id *Identifier) FromRandom(max uint64) (string, error) {
 	n := uint64(id.r.Int63n(int64(max-1)) + int64(1))
 	return id.FromValue(n, max)
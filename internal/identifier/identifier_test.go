@@ -1,6 +1,8 @@
 package identifier_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/datum-cloud/galactic-operator/internal/identifier"
@@ -63,3 +65,70 @@ func TestForVPCAttachment(t *testing.T) {
 		})
 	}
 }
+
+func TestFromString(t *testing.T) {
+	id := identifier.NewFromSeed(424242)
+	tests := []struct {
+		name           string
+		value          string
+		max            uint64
+		wantIdentifier string
+		wantError      bool
+	}{
+		{"VPCCanonical", "000000003039", identifier.MaxVPC, "000000003039", false},
+		{"VPCShort", "3039", identifier.MaxVPC, "000000003039", false},
+		{"VPCUpperCase", "ABCDEF", identifier.MaxVPC, "000000abcdef", false},
+		{"VPCAttachment", "e513", identifier.MaxVPCAttachment, "e513", false},
+		{"InvalidSpecialMin", "0", identifier.MaxVPC, "", true},
+		{"InvalidSpecialMax", "ffff", identifier.MaxVPCAttachment, "", true},
+		{"InvalidMax", "10000", identifier.MaxVPCAttachment, "", true},
+		{"InvalidHex", "xyz", identifier.MaxVPC, "", true},
+		{"InvalidEmpty", "", identifier.MaxVPC, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := id.FromString(tt.value, tt.max)
+			if (err != nil) != tt.wantError {
+				t.Errorf("FromString() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if got != tt.wantIdentifier {
+				t.Errorf("FromString() got = %v, want = %v", got, tt.wantIdentifier)
+			}
+		})
+	}
+}
+
+func TestLowestFree(t *testing.T) {
+	id := identifier.NewFromSeed(424242)
+	full := make([]string, 0, identifier.MaxVPCAttachment-1)
+	for n := uint64(1); n < identifier.MaxVPCAttachment; n++ {
+		full = append(full, fmt.Sprintf("%04x", n))
+	}
+	tests := []struct {
+		name           string
+		used           []string
+		max            uint64
+		wantIdentifier string
+		wantExhausted  bool
+	}{
+		{"Empty", nil, identifier.MaxVPCAttachment, "0001", false},
+		{"FillsGaps", []string{"0001", "0003"}, identifier.MaxVPCAttachment, "0002", false},
+		{"SkipsUsed", []string{"0002", "0001"}, identifier.MaxVPCAttachment, "0003", false},
+		{"IgnoresInvalid", []string{"not-hex"}, identifier.MaxVPC, "000000000001", false},
+		{"LastFree", full[1:], identifier.MaxVPCAttachment, "0001", false},
+		{"Exhausted", full, identifier.MaxVPCAttachment, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := id.LowestFree(tt.used, tt.max)
+			if errors.Is(err, identifier.ErrSpaceExhausted) != tt.wantExhausted {
+				t.Errorf("LowestFree() error = %v, wantExhausted = %v", err, tt.wantExhausted)
+			}
+			if got != tt.wantIdentifier {
+				t.Errorf("LowestFree() got = %v, want = %v", got, tt.wantIdentifier)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// Allocate returns one free address for every address family present in
+// networks, in CIDR notation using the prefix length of the network the
+// address was taken from. Networks are tried in order and the lowest free
+// address is used. The network address itself, the IPv4 broadcast address and
+// every address in used are never handed out.
+func Allocate(networks []string, used []string) ([]string, error) {
+	usedAddrs := make(map[netip.Addr]struct{}, len(used))
+	for _, address := range used {
+		if addr, err := parseAddr(address); err == nil {
+			usedAddrs[addr] = struct{}{}
+		}
+	}
+
+	return allocateByFamily(networks, func(prefixes []netip.Prefix) (string, bool) {
+		return allocateFromPrefixes(prefixes, usedAddrs)
+	})
+}
+
+// AllocateNth returns for every address family present in networks the n-th
+// address, counting from zero, that Allocate hands out if no address is in
+// use. It gives Pods with an ordinal, like those of a StatefulSet, stable
+// addresses. If that address is in use, the lowest free address of the
+// family is returned like Allocate does.
+func AllocateNth(networks []string, used []string, n int) ([]string, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid index %d", n)
+	}
+	usedAddrs := make(map[netip.Addr]struct{}, len(used))
+	for _, address := range used {
+		if addr, err := parseAddr(address); err == nil {
+			usedAddrs[addr] = struct{}{}
+		}
+	}
+
+	return allocateByFamily(networks, func(prefixes []netip.Prefix) (string, bool) {
+		if address, ok := nthFromPrefixes(prefixes, n); ok {
+			if addr, err := parseAddr(address); err == nil {
+				if _, taken := usedAddrs[addr]; !taken {
+					return address, true
+				}
+			}
+		}
+		return allocateFromPrefixes(prefixes, usedAddrs)
+	})
+}
+
+// HasNth reports whether every address family present in networks has an
+// n-th address, counting from zero, that AllocateNth can hand out.
+func HasNth(networks []string, n int) bool {
+	if n < 0 {
+		return false
+	}
+	_, err := allocateByFamily(networks, func(prefixes []netip.Prefix) (string, bool) {
+		return nthFromPrefixes(prefixes, n)
+	})
+	return err == nil
+}
+
+// WithPrefixOf returns address in CIDR notation using the prefix length of the
+// first of networks containing it, or address unchanged if none does.
+func WithPrefixOf(networks []string, address string) string {
+	addr, err := parseAddr(address)
+	if err != nil {
+		return address
+	}
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr) {
+			return netip.PrefixFrom(addr, prefix.Bits()).String()
+		}
+	}
+	return address
+}
+
+// allocateByFamily groups networks by address family and picks one address
+// per family, in the order the families first appear in networks.
+func allocateByFamily(networks []string, pick func(prefixes []netip.Prefix) (string, bool)) ([]string, error) {
+	prefixesByFamily := make(map[bool][]netip.Prefix, 2)
+	families := make([]bool, 0, 2)
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, err
+		}
+		prefix = prefix.Masked()
+		is4 := prefix.Addr().Is4()
+		if _, exists := prefixesByFamily[is4]; !exists {
+			families = append(families, is4)
+		}
+		prefixesByFamily[is4] = append(prefixesByFamily[is4], prefix)
+	}
+
+	addresses := make([]string, 0, len(families))
+	for _, is4 := range families {
+		address, ok := pick(prefixesByFamily[is4])
+		if !ok {
+			return nil, fmt.Errorf("no free %s address left in networks %v", familyName(is4), prefixesByFamily[is4])
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// Contains reports whether address lies within one of networks.
+func Contains(networks []string, address string) bool {
+	addr, err := parseAddr(address)
+	if err != nil {
+		return false
+	}
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func allocateFromPrefixes(prefixes []netip.Prefix, used map[netip.Addr]struct{}) (string, bool) {
+	for _, prefix := range prefixes {
+		for addr := prefix.Addr().Next(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+			if isBroadcast(prefix, addr) {
+				break
+			}
+			if _, taken := used[addr]; taken {
+				continue
+			}
+			return netip.PrefixFrom(addr, prefix.Bits()).String(), true
+		}
+	}
+	return "", false
+}
+
+// nthFromPrefixes returns the n-th address Allocate hands out from prefixes
+// if no address is in use. It is computed from the size of the prefixes, so
+// that large indexes and networks take no longer than small ones.
+func nthFromPrefixes(prefixes []netip.Prefix, n int) (string, bool) {
+	remaining := uint64(n)
+	for _, prefix := range prefixes {
+		hosts, ok := hostCount(prefix)
+		if ok && remaining >= hosts {
+			remaining -= hosts
+			continue
+		}
+		// The network address itself is never handed out
+		addr := addOffset(prefix.Addr(), remaining+1)
+		return netip.PrefixFrom(addr, prefix.Bits()).String(), true
+	}
+	return "", false
+}
+
+// hostCount returns the number of addresses Allocate hands out from prefix. It
+// reports false if the number does not fit into 63 bits, which no index
+// reaches.
+func hostCount(prefix netip.Prefix) (uint64, bool) {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 63 {
+		return 0, false
+	}
+	hosts := uint64(1)<<hostBits - 1
+	if prefix.Addr().Is4() && prefix.Bits() < 31 {
+		hosts--
+	}
+	return hosts, true
+}
+
+// addOffset returns addr advanced by offset addresses.
+func addOffset(addr netip.Addr, offset uint64) netip.Addr {
+	bytes := addr.As16()
+	for i := len(bytes) - 1; i >= 0 && offset > 0; i-- {
+		sum := uint64(bytes[i]) + offset&0xff
+		bytes[i] = byte(sum)
+		offset = offset>>8 + sum>>8
+	}
+	result := netip.AddrFrom16(bytes)
+	if addr.Is4() {
+		return result.Unmap()
+	}
+	return result
+}
+
+// isBroadcast reports whether addr is the last address of an IPv4 network
+// that is large enough to have a broadcast address.
+func isBroadcast(prefix netip.Prefix, addr netip.Addr) bool {
+	if !addr.Is4() || prefix.Bits() >= 31 {
+		return false
+	}
+	next := addr.Next()
+	return !next.IsValid() || !prefix.Contains(next)
+}
+
+// parseAddr accepts both plain addresses and addresses in CIDR notation.
+func parseAddr(address string) (netip.Addr, error) {
+	if prefix, err := netip.ParsePrefix(address); err == nil {
+		return prefix.Addr(), nil
+	}
+	return netip.ParseAddr(address)
+}
+
+func familyName(is4 bool) string {
+	if is4 {
+		return "IPv4"
+	}
+	return "IPv6"
+}
@@ -0,0 +1,146 @@
+package ipam_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/datum-cloud/galactic-operator/internal/ipam"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name          string
+		networks      []string
+		used          []string
+		wantAddresses []string
+		wantError     bool
+	}{
+		{"DualStack", []string{"10.1.1.0/24", "2001:10:1:1::/64"}, nil, []string{"10.1.1.1/24", "2001:10:1:1::1/64"}, false},
+		{"SingleStack", []string{"10.1.1.0/24"}, nil, []string{"10.1.1.1/24"}, false},
+		{"SkipsUsed", []string{"10.1.1.0/24", "2001:10:1:1::/64"}, []string{"10.1.1.1/24", "10.1.1.2/24", "2001:10:1:1::1/64"}, []string{"10.1.1.3/24", "2001:10:1:1::2/64"}, false},
+		{"SkipsUsedPlainAddresses", []string{"10.1.1.0/24"}, []string{"10.1.1.1"}, []string{"10.1.1.2/24"}, false},
+		{"FillsGaps", []string{"10.1.1.0/24"}, []string{"10.1.1.1/24", "10.1.1.3/24"}, []string{"10.1.1.2/24"}, false},
+		{"OneAddressPerFamily", []string{"10.1.1.0/24", "10.1.2.0/24"}, nil, []string{"10.1.1.1/24"}, false},
+		{"FallsBackToNextNetwork", []string{"10.1.1.0/30", "10.1.2.0/24"}, []string{"10.1.1.1/30", "10.1.1.2/30"}, []string{"10.1.2.1/24"}, false},
+		{"SkipsBroadcast", []string{"10.1.1.0/30"}, []string{"10.1.1.1/30", "10.1.1.2/30"}, nil, true},
+		{"ExhaustedIPv6", []string{"2001:10:1:1::/126"}, []string{"2001:10:1:1::1/126", "2001:10:1:1::2/126", "2001:10:1:1::3/126"}, nil, true},
+		{"InvalidNetwork", []string{"not-a-cidr"}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ipam.Allocate(tt.networks, tt.used)
+			if (err != nil) != tt.wantError {
+				t.Errorf("Allocate() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if !slices.Equal(got, tt.wantAddresses) {
+				t.Errorf("Allocate() got = %v, want = %v", got, tt.wantAddresses)
+			}
+		})
+	}
+}
+
+func TestAllocateNth(t *testing.T) {
+	tests := []struct {
+		name          string
+		networks      []string
+		used          []string
+		n             int
+		wantAddresses []string
+		wantError     bool
+	}{
+		{"First", []string{"10.1.1.0/24", "2001:10:1:1::/64"}, nil, 0, []string{"10.1.1.1/24", "2001:10:1:1::1/64"}, false},
+		{"Ordinal", []string{"10.1.1.0/24", "2001:10:1:1::/64"}, nil, 4, []string{"10.1.1.5/24", "2001:10:1:1::5/64"}, false},
+		{"ContinuesInNextNetwork", []string{"10.1.1.0/30", "10.1.2.0/24"}, nil, 2, []string{"10.1.2.1/24"}, false},
+		{"FallsBackToLowestFree", []string{"10.1.1.0/24", "2001:10:1:1::/64"}, []string{"10.1.1.5/24", "10.1.1.1/24"}, 4,
+			[]string{"10.1.1.2/24", "2001:10:1:1::5/64"}, false},
+		{"FallsBackBeyondNetworks", []string{"10.1.1.0/30"}, nil, 2, []string{"10.1.1.1/30"}, false},
+		{"LargeIndex", []string{"10.1.1.0/24", "2001:10:1:1::/64"}, nil, math.MaxInt32,
+			[]string{"10.1.1.1/24", "2001:10:1:1::8000:0/64"}, false},
+		{"CarriesIntoHigherBytes", []string{"10.1.0.0/16"}, nil, 299, []string{"10.1.1.44/16"}, false},
+		{"Exhausted", []string{"10.1.1.0/30"}, []string{"10.1.1.1", "10.1.1.2"}, 0, nil, true},
+		{"NegativeIndex", []string{"10.1.1.0/24"}, nil, -1, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ipam.AllocateNth(tt.networks, tt.used, tt.n)
+			if (err != nil) != tt.wantError {
+				t.Errorf("AllocateNth() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if !slices.Equal(got, tt.wantAddresses) {
+				t.Errorf("AllocateNth() got = %v, want = %v", got, tt.wantAddresses)
+			}
+		})
+	}
+}
+
+func TestHasNth(t *testing.T) {
+	tests := []struct {
+		name     string
+		networks []string
+		n        int
+		want     bool
+	}{
+		{"First", []string{"10.1.1.0/24"}, 0, true},
+		{"LastHost", []string{"10.1.1.0/24"}, 253, true},
+		{"Broadcast", []string{"10.1.1.0/24"}, 254, false},
+		{"NextNetwork", []string{"10.1.1.0/30", "10.1.2.0/30"}, 3, true},
+		{"BeyondIPv4OfDualStack", []string{"10.1.1.0/30", "2001:10:1:1::/64"}, 2, false},
+		{"LargeIPv6", []string{"2001:10:1:1::/64"}, math.MaxInt32, true},
+		{"HostNetwork", []string{"10.1.1.1/32"}, 0, false},
+		{"NegativeIndex", []string{"10.1.1.0/24"}, -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ipam.HasNth(tt.networks, tt.n); got != tt.want {
+				t.Errorf("HasNth() got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPrefixOf(t *testing.T) {
+	networks := []string{"10.1.1.0/24", "2001:10:1:1::/64"}
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"10.1.1.129/25", "10.1.1.129/24"},
+		{"2001:10:1:1::1", "2001:10:1:1::1/64"},
+		{"10.1.2.1/25", "10.1.2.1/25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := ipam.WithPrefixOf(networks, tt.address); got != tt.want {
+				t.Errorf("WithPrefixOf() got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	networks := []string{"10.1.1.0/24", "2001:10:1:1::/64"}
+	tests := []struct {
+		name     string
+		address  string
+		wantBool bool
+	}{
+		{"ContainedIPv4", "10.1.1.1/24", true},
+		{"ContainedIPv6", "2001:10:1:1::1/64", true},
+		{"ContainedPlainAddress", "10.1.1.1", true},
+		{"NotContained", "10.1.2.1/24", false},
+		{"Invalid", "not-an-address", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ipam.Contains(networks, tt.address); got != tt.wantBool {
+				t.Errorf("Contains() got = %v, want = %v", got, tt.wantBool)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package peering
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+)
+
+// State is the outcome of evaluating a VPCPeering. Peer is only set once the
+// VPCs are connected, otherwise Reason and Message tell why they are not.
+type State struct {
+	Accepted bool
+	Peer     *galacticv1alpha.VPC
+	Reason   string
+	Message  string
+}
+
+// VPC returns the namespaced name of the VPC the VPCPeering connects.
+func VPC(vpcPeering *galacticv1alpha.VPCPeering) types.NamespacedName {
+	return types.NamespacedName{Namespace: vpcPeering.Namespace, Name: vpcPeering.Spec.VPC}
+}
+
+// PeerVPC returns the namespaced name of the VPC the VPCPeering connects to.
+func PeerVPC(vpcPeering *galacticv1alpha.VPCPeering) types.NamespacedName {
+	namespace := vpcPeering.Spec.PeerVPC.Namespace
+	if namespace == "" {
+		namespace = vpcPeering.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: vpcPeering.Spec.PeerVPC.Name}
+}
+
+// OverlappingNetworks returns the first network of a overlapping with a
+// network of b, along with that network.
+func OverlappingNetworks(a, b []string) (string, string, bool) {
+	for _, networkA := range a {
+		_, ipNetA, err := cniconfig.ParseAddress(networkA)
+		if err != nil {
+			continue
+		}
+		for _, networkB := range b {
+			_, ipNetB, err := cniconfig.ParseAddress(networkB)
+			if err != nil {
+				continue
+			}
+			if cniconfig.NetworksOverlap(ipNetA, ipNetB) {
+				return networkA, networkB, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Accepted reports whether the namespace of the peer VPC holds a VPCPeering
+// in the opposite direction.
+func Accepted(ctx context.Context, c client.Reader, vpcPeering *galacticv1alpha.VPCPeering) (bool, error) {
+	vpc, peerVPC := VPC(vpcPeering), PeerVPC(vpcPeering)
+	var vpcPeerings galacticv1alpha.VPCPeeringList
+	if err := c.List(ctx, &vpcPeerings, client.InNamespace(peerVPC.Namespace)); err != nil {
+		return false, err
+	}
+	for i := range vpcPeerings.Items {
+		other := &vpcPeerings.Items[i]
+		if other.DeletionTimestamp.IsZero() && VPC(other) == peerVPC && PeerVPC(other) == vpc {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Evaluate checks whether the VPCPeering connects its VPCs: the peering must
+// be accepted, both VPCs ready and their networks must not overlap, neither
+// with each other nor with the networks of another VPC peered with the VPC.
+func Evaluate(ctx context.Context, c client.Reader, vpcPeering *galacticv1alpha.VPCPeering) (State, error) {
+	state, err := evaluate(ctx, c, vpcPeering)
+	if err != nil || state.Peer == nil {
+		return state, err
+	}
+
+	peers, err := connectedPeers(ctx, c, VPC(vpcPeering))
+	if err != nil {
+		return State{}, err
+	}
+	if other, network, otherNetwork, overlap := overlappingPeer(peers, state.Peer); overlap {
+		return State{
+			Accepted: true,
+			Reason:   galacticv1alpha.VPCPeeringReasonNetworksOverlap,
+			Message: fmt.Sprintf("network %s of VPC %s overlaps with network %s of VPC %s, which VPC %s peers with as well",
+				network, PeerVPC(vpcPeering), otherNetwork, client.ObjectKeyFromObject(other), VPC(vpcPeering)),
+		}, nil
+	}
+	return state, nil
+}
+
+// evaluate checks whether the VPCPeering connects its VPCs, regardless of the
+// other VPCPeerings of the VPC.
+func evaluate(ctx context.Context, c client.Reader, vpcPeering *galacticv1alpha.VPCPeering) (State, error) {
+	accepted, err := Accepted(ctx, c, vpcPeering)
+	if err != nil {
+		return State{}, err
+	}
+	peerVPCName := PeerVPC(vpcPeering)
+	if !accepted {
+		return State{
+			Reason:  galacticv1alpha.VPCPeeringReasonPendingAcceptance,
+			Message: fmt.Sprintf("waiting for a VPCPeering from VPC %s to VPC %s", peerVPCName, VPC(vpcPeering)),
+		}, nil
+	}
+
+	var vpcs [2]galacticv1alpha.VPC
+	for i, name := range []types.NamespacedName{VPC(vpcPeering), peerVPCName} {
+		if err := c.Get(ctx, name, &vpcs[i]); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return State{}, err
+			}
+			return State{
+				Accepted: true,
+				Reason:   galacticv1alpha.VPCPeeringReasonVPCNotFound,
+				Message:  fmt.Sprintf("VPC %s not found", name),
+			}, nil
+		}
+		if !vpcs[i].Status.Ready {
+			return State{
+				Accepted: true,
+				Reason:   galacticv1alpha.VPCPeeringReasonVPCNotReady,
+				Message:  fmt.Sprintf("VPC %s is not ready", name),
+			}, nil
+		}
+	}
+
+	if network, peerNetwork, overlap := OverlappingNetworks(vpcs[0].Spec.Networks, vpcs[1].Spec.Networks); overlap {
+		return State{
+			Accepted: true,
+			Reason:   galacticv1alpha.VPCPeeringReasonNetworksOverlap,
+			Message:  fmt.Sprintf("network %s overlaps with network %s of VPC %s", network, peerNetwork, peerVPCName),
+		}, nil
+	}
+	return State{Accepted: true, Peer: &vpcs[1]}, nil
+}
+
+// PeerVPCs returns the VPCs connected to the VPC by its VPCPeerings. Peer VPCs
+// whose networks overlap with each other are all left out, as traffic to the
+// overlapping networks could not be routed to either of them.
+func PeerVPCs(ctx context.Context, c client.Reader, vpc types.NamespacedName) ([]galacticv1alpha.VPC, error) {
+	peers, err := connectedPeers(ctx, c, vpc)
+	if err != nil {
+		return nil, err
+	}
+	var disjoint []galacticv1alpha.VPC
+	for i := range peers {
+		if _, _, _, overlap := overlappingPeer(peers, &peers[i]); !overlap {
+			disjoint = append(disjoint, peers[i])
+		}
+	}
+	return disjoint, nil
+}
+
+// overlappingPeer returns the first of peers other than peer whose networks
+// overlap with the networks of peer, along with the overlapping networks.
+func overlappingPeer(peers []galacticv1alpha.VPC, peer *galacticv1alpha.VPC) (*galacticv1alpha.VPC, string, string, bool) {
+	for i := range peers {
+		if client.ObjectKeyFromObject(&peers[i]) == client.ObjectKeyFromObject(peer) {
+			continue
+		}
+		if network, otherNetwork, overlap := OverlappingNetworks(peer.Spec.Networks, peers[i].Spec.Networks); overlap {
+			return &peers[i], network, otherNetwork, true
+		}
+	}
+	return nil, "", "", false
+}
+
+// connectedPeers returns the VPCs connected to the VPC by its VPCPeerings,
+// regardless of whether they overlap with each other.
+func connectedPeers(ctx context.Context, c client.Reader, vpc types.NamespacedName) ([]galacticv1alpha.VPC, error) {
+	var vpcPeerings galacticv1alpha.VPCPeeringList
+	if err := c.List(ctx, &vpcPeerings, client.InNamespace(vpc.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var peers []galacticv1alpha.VPC
+	seen := make(map[types.NamespacedName]struct{})
+	for i := range vpcPeerings.Items {
+		vpcPeering := &vpcPeerings.Items[i]
+		if !vpcPeering.DeletionTimestamp.IsZero() || VPC(vpcPeering) != vpc {
+			continue
+		}
+		if _, exists := seen[PeerVPC(vpcPeering)]; exists {
+			continue
+		}
+		state, err := evaluate(ctx, c, vpcPeering)
+		if err != nil {
+			return nil, err
+		}
+		if state.Peer != nil {
+			seen[PeerVPC(vpcPeering)] = struct{}{}
+			peers = append(peers, *state.Peer)
+		}
+	}
+	return peers, nil
+}
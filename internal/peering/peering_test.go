@@ -0,0 +1,177 @@
+package peering_test
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/peering"
+)
+
+func TestOverlappingNetworks(t *testing.T) {
+	tests := []struct {
+		name        string
+		a, b        []string
+		wantOverlap bool
+	}{
+		{"Disjoint", []string{"10.1.0.0/16", "2001:10:1::/48"}, []string{"10.2.0.0/16", "2001:10:2::/48"}, false},
+		{"Contained", []string{"10.1.0.0/16"}, []string{"10.2.0.0/16", "10.1.1.0/24"}, true},
+		{"Equal", []string{"2001:10:1::/48"}, []string{"2001:10:1::/48"}, true},
+		{"OtherFamilies", []string{"10.1.0.0/16"}, []string{"2001:10:1::/48"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, overlap := peering.OverlappingNetworks(tt.a, tt.b); overlap != tt.wantOverlap {
+				t.Errorf("OverlappingNetworks() got = %v, want = %v", overlap, tt.wantOverlap)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	vpc := func(namespace, name string, networks ...string) *galacticv1alpha.VPC {
+		return &galacticv1alpha.VPC{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       galacticv1alpha.VPCSpec{Networks: networks},
+			Status:     galacticv1alpha.VPCStatus{Ready: true, Identifier: "1"},
+		}
+	}
+	vpcPeering := func(namespace, vpcName, peerNamespace, peerName string) *galacticv1alpha.VPCPeering {
+		return &galacticv1alpha.VPCPeering{
+			ObjectMeta: metav1.ObjectMeta{Name: vpcName + "-to-" + peerName, Namespace: namespace},
+			Spec: galacticv1alpha.VPCPeeringSpec{
+				VPC:     vpcName,
+				PeerVPC: galacticv1alpha.VPCPeeringPeer{Name: peerName, Namespace: peerNamespace},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		objects    []client.Object
+		wantReason string
+		wantPeer   bool
+	}{
+		{"Pending", []client.Object{
+			vpc("team-a", "vpc-a", "10.1.0.0/16"), vpc("team-b", "vpc-b", "10.2.0.0/16"),
+		}, galacticv1alpha.VPCPeeringReasonPendingAcceptance, false},
+		{"AcceptedForOtherVPC", []client.Object{
+			vpc("team-a", "vpc-a", "10.1.0.0/16"), vpc("team-b", "vpc-b", "10.2.0.0/16"),
+			vpcPeering("team-b", "vpc-b", "team-a", "vpc-c"),
+		}, galacticv1alpha.VPCPeeringReasonPendingAcceptance, false},
+		{"PeerVPCNotFound", []client.Object{
+			vpc("team-a", "vpc-a", "10.1.0.0/16"),
+			vpcPeering("team-b", "vpc-b", "team-a", "vpc-a"),
+		}, galacticv1alpha.VPCPeeringReasonVPCNotFound, false},
+		{"Overlap", []client.Object{
+			vpc("team-a", "vpc-a", "10.1.0.0/16"), vpc("team-b", "vpc-b", "10.1.1.0/24"),
+			vpcPeering("team-b", "vpc-b", "team-a", "vpc-a"),
+		}, galacticv1alpha.VPCPeeringReasonNetworksOverlap, false},
+		{"Connected", []client.Object{
+			vpc("team-a", "vpc-a", "10.1.0.0/16"), vpc("team-b", "vpc-b", "10.2.0.0/16"),
+			vpcPeering("team-b", "vpc-b", "team-a", "vpc-a"),
+		}, "", true},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := galacticv1alpha.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := vpcPeering("team-a", "vpc-a", "team-b", "vpc-b")
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(tt.objects, local)...).Build()
+
+			state, err := peering.Evaluate(context.Background(), c, local)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if state.Reason != tt.wantReason || (state.Peer != nil) != tt.wantPeer {
+				t.Errorf("Evaluate() got = %+v, want reason %q and peer %v", state, tt.wantReason, tt.wantPeer)
+			}
+
+			peers, err := peering.PeerVPCs(context.Background(), c, types.NamespacedName{Namespace: "team-a", Name: "vpc-a"})
+			if err != nil {
+				t.Fatalf("PeerVPCs() error = %v", err)
+			}
+			if len(peers) > 1 || (len(peers) == 1) != tt.wantPeer {
+				t.Errorf("PeerVPCs() got = %v, want peer %v", peers, tt.wantPeer)
+			}
+		})
+	}
+}
+
+func TestPeerVPCsOverlappingEachOther(t *testing.T) {
+	vpc := func(name string, networks ...string) *galacticv1alpha.VPC {
+		return &galacticv1alpha.VPC{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       galacticv1alpha.VPCSpec{Networks: networks},
+			Status:     galacticv1alpha.VPCStatus{Ready: true, Identifier: "1"},
+		}
+	}
+	vpcPeering := func(vpcName, peerName string) *galacticv1alpha.VPCPeering {
+		return &galacticv1alpha.VPCPeering{
+			ObjectMeta: metav1.ObjectMeta{Name: vpcName + "-to-" + peerName, Namespace: "default"},
+			Spec: galacticv1alpha.VPCPeeringSpec{
+				VPC:     vpcName,
+				PeerVPC: galacticv1alpha.VPCPeeringPeer{Name: peerName},
+			},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	if err := galacticv1alpha.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	objects := []client.Object{
+		vpc("vpc-a", "10.1.0.0/16"),
+		vpc("vpc-b", "10.2.0.0/16"),
+		vpc("vpc-c", "10.2.1.0/24"),
+		vpc("vpc-d", "10.4.0.0/16"),
+	}
+	for _, peer := range []string{"vpc-b", "vpc-c", "vpc-d"} {
+		objects = append(objects, vpcPeering("vpc-a", peer), vpcPeering(peer, "vpc-a"))
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	peers, err := peering.PeerVPCs(context.Background(), c, types.NamespacedName{Namespace: "default", Name: "vpc-a"})
+	if err != nil {
+		t.Fatalf("PeerVPCs() error = %v", err)
+	}
+	if len(peers) != 1 || peers[0].Name != "vpc-d" {
+		t.Errorf("PeerVPCs() got = %v, want only vpc-d", peers)
+	}
+
+	for _, tt := range []struct {
+		peer       string
+		wantReason string
+	}{
+		{"vpc-b", galacticv1alpha.VPCPeeringReasonNetworksOverlap},
+		{"vpc-c", galacticv1alpha.VPCPeeringReasonNetworksOverlap},
+		{"vpc-d", ""},
+	} {
+		state, err := peering.Evaluate(context.Background(), c, vpcPeering("vpc-a", tt.peer))
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if state.Reason != tt.wantReason || (state.Peer != nil) != (tt.wantReason == "") {
+			t.Errorf("Evaluate() of the peering with %s got = %+v, want reason %q", tt.peer, state, tt.wantReason)
+		}
+	}
+
+	// The peers only overlap with each other, not from their own point of view
+	peers, err = peering.PeerVPCs(context.Background(), c, types.NamespacedName{Namespace: "default", Name: "vpc-b"})
+	if err != nil {
+		t.Fatalf("PeerVPCs() error = %v", err)
+	}
+	if len(peers) != 1 || peers[0].Name != "vpc-a" {
+		t.Errorf("PeerVPCs() got = %v, want only vpc-a", peers)
+	}
+}
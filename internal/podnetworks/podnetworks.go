@@ -0,0 +1,255 @@
+package podnetworks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+)
+
+// ParseVPCAttachmentNames parses the value of the VPCAttachment annotation of
+// a Pod. It accepts a single name, a comma-separated list of names or a JSON
+// array of names.
+func ParseVPCAttachmentNames(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	var names []string
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &names); err != nil {
+			return nil, fmt.Errorf("invalid JSON list of VPCAttachments: %w", err)
+		}
+	} else {
+		names = strings.Split(value, ",")
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+		if names[i] == "" {
+			return nil, fmt.Errorf("empty VPCAttachment name in %q", value)
+		}
+		if _, exists := seen[names[i]]; exists {
+			return nil, fmt.Errorf("duplicate VPCAttachment %s", names[i])
+		}
+		seen[names[i]] = struct{}{}
+	}
+	return names, nil
+}
+
+// VPCAttachmentNames returns the names of the VPCAttachments referenced by the
+// annotations of a Pod.
+func VPCAttachmentNames(annotations map[string]string) ([]string, error) {
+	return ParseVPCAttachmentNames(annotations[galacticv1alpha.VPCAttachmentAnnotation])
+}
+
+// PodIsActive reports whether the Pod may still be using its network
+// attachments, i.e. it has not reached a terminal phase.
+func PodIsActive(pod *corev1.Pod) bool {
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// ActivePodsUsingVPCAttachment returns the active Pods among pods whose
+// annotation references the VPCAttachment name.
+func ActivePodsUsingVPCAttachment(pods []corev1.Pod, name string) []corev1.Pod {
+	var active []corev1.Pod
+	for _, pod := range pods {
+		// Pods with an invalid annotation are rejected by the webhook
+		names, _ := VPCAttachmentNames(pod.Annotations)
+		if PodIsActive(&pod) && slices.Contains(names, name) {
+			active = append(active, pod)
+		}
+	}
+	return active
+}
+
+// ValidateInterfaceNames rejects VPCAttachments that would create the same
+// interface inside a Pod.
+func ValidateInterfaceNames(vpcAttachments []galacticv1alpha.VPCAttachment) error {
+	usedBy := make(map[string]string, len(vpcAttachments))
+	for _, vpcAttachment := range vpcAttachments {
+		interfaceName := vpcAttachment.Spec.Interface.Name
+		if other, exists := usedBy[interfaceName]; exists {
+			return fmt.Errorf("VPCAttachments %s and %s both use interface %s", other, vpcAttachment.Name, interfaceName)
+		}
+		usedBy[interfaceName] = vpcAttachment.Name
+	}
+	return nil
+}
+
+// NetworkSelectionElements renders the Multus network selection elements
+// attaching a Pod to the NetworkAttachmentDefinitions of the VPCAttachments.
+func NetworkSelectionElements(vpcAttachments []galacticv1alpha.VPCAttachment) []nadv1.NetworkSelectionElement {
+	elements := make([]nadv1.NetworkSelectionElement, 0, len(vpcAttachments))
+	for _, vpcAttachment := range vpcAttachments {
+		var gateways []net.IP
+		for _, gateway := range vpcAttachment.Spec.Interface.DefaultRoute {
+			if ip := net.ParseIP(gateway); ip != nil {
+				gateways = append(gateways, ip)
+			}
+		}
+		elements = append(elements, nadv1.NetworkSelectionElement{
+			Name:             vpcAttachment.Name,
+			Namespace:        vpcAttachment.Namespace,
+			InterfaceRequest: vpcAttachment.Spec.Interface.Name,
+			IPRequest:        cniconfig.InterfaceAddresses(vpcAttachment),
+			MacRequest:       vpcAttachment.Spec.Interface.MAC,
+			GatewayRequest:   gateways,
+		})
+	}
+	return elements
+}
+
+// ParseNetworkSelectionElements parses the value of the Multus networks
+// annotation of a Pod, either in the JSON form or as a comma-separated list of
+// [namespace/]name[@interface] entries.
+func ParseNetworkSelectionElements(value string) ([]nadv1.NetworkSelectionElement, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(value, "[") {
+		var elements []nadv1.NetworkSelectionElement
+		if err := json.Unmarshal([]byte(value), &elements); err != nil {
+			return nil, fmt.Errorf("invalid JSON list of networks: %w", err)
+		}
+		return elements, nil
+	}
+
+	var elements []nadv1.NetworkSelectionElement
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return nil, fmt.Errorf("empty network in %q", value)
+		}
+		var element nadv1.NetworkSelectionElement
+		if namespace, name, found := strings.Cut(entry, "/"); found {
+			element.Namespace = namespace
+			entry = name
+		}
+		if name, interfaceName, found := strings.Cut(entry, "@"); found {
+			element.InterfaceRequest = interfaceName
+			entry = name
+		}
+		element.Name = entry
+		if element.Name == "" {
+			return nil, fmt.Errorf("network without a name in %q", value)
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
+// MergeNetworkSelectionElements replaces the elements of existing selecting
+// one of the managed networks of a Pod in namespace and appends the remaining
+// managed elements, preserving every other network requested for the Pod.
+func MergeNetworkSelectionElements(existing, managed []nadv1.NetworkSelectionElement, namespace string) ([]nadv1.NetworkSelectionElement, error) {
+	managedIndex := make(map[string]int, len(managed))
+	managedInterfaces := make(map[string]struct{}, len(managed))
+	for i, element := range managed {
+		managedIndex[element.Name] = i
+		if element.InterfaceRequest != "" {
+			managedInterfaces[element.InterfaceRequest] = struct{}{}
+		}
+	}
+
+	merged := make([]nadv1.NetworkSelectionElement, 0, len(existing)+len(managed))
+	placed := make(map[string]struct{}, len(managed))
+	for _, element := range existing {
+		i, isManaged := managedIndex[element.Name]
+		if isManaged && (element.Namespace == "" || element.Namespace == namespace) {
+			if _, exists := placed[element.Name]; !exists {
+				merged = append(merged, managed[i])
+				placed[element.Name] = struct{}{}
+			}
+			continue
+		}
+		if _, exists := managedInterfaces[element.InterfaceRequest]; exists {
+			return nil, fmt.Errorf("network %s requests interface %s which is used by a VPCAttachment", element.Name, element.InterfaceRequest)
+		}
+		merged = append(merged, element)
+	}
+	for _, element := range managed {
+		if _, exists := placed[element.Name]; !exists {
+			merged = append(merged, element)
+		}
+	}
+	return merged, nil
+}
+
+// ManagedNetworkSelectionElements returns the elements selecting one of the
+// VPCAttachments names of a Pod in namespace.
+func ManagedNetworkSelectionElements(elements []nadv1.NetworkSelectionElement, names []string, namespace string) []nadv1.NetworkSelectionElement {
+	var managed []nadv1.NetworkSelectionElement
+	for _, element := range elements {
+		if slices.Contains(names, element.Name) && (element.Namespace == "" || element.Namespace == namespace) {
+			managed = append(managed, element)
+		}
+	}
+	return managed
+}
+
+// VPCInterfacesReady reports whether the Multus network status of the Pod
+// shows the interfaces of all its VPCAttachments with the requested
+// addresses. Otherwise the message tells what is missing.
+func VPCInterfacesReady(pod *corev1.Pod) (bool, string, error) {
+	names, err := VPCAttachmentNames(pod.Annotations)
+	if err != nil {
+		return false, "", err
+	}
+	elements, err := ParseNetworkSelectionElements(pod.Annotations[nadv1.NetworkAttachmentAnnot])
+	if err != nil {
+		return false, "", err
+	}
+	managed := ManagedNetworkSelectionElements(elements, names, pod.Namespace)
+
+	value, exists := pod.Annotations[nadv1.NetworkStatusAnnot]
+	if !exists {
+		return false, "network status not reported", nil
+	}
+	var statuses []nadv1.NetworkStatus
+	if err := json.Unmarshal([]byte(value), &statuses); err != nil {
+		return false, "", fmt.Errorf("invalid network status: %w", err)
+	}
+
+	for _, name := range names {
+		i := slices.IndexFunc(managed, func(element nadv1.NetworkSelectionElement) bool {
+			return element.Name == name
+		})
+		if i < 0 {
+			return false, fmt.Sprintf("VPCAttachment %s is not requested from Multus", name), nil
+		}
+		element := managed[i]
+		j := slices.IndexFunc(statuses, func(status nadv1.NetworkStatus) bool {
+			return status.Name == pod.Namespace+"/"+name &&
+				(element.InterfaceRequest == "" || status.Interface == element.InterfaceRequest)
+		})
+		if j < 0 {
+			return false, fmt.Sprintf("interface %s of VPCAttachment %s not reported", element.InterfaceRequest, name), nil
+		}
+		for _, address := range element.IPRequest {
+			ip, _, err := net.ParseCIDR(address)
+			if err != nil {
+				return false, "", fmt.Errorf("invalid address %s requested for VPCAttachment %s: %w", address, name, err)
+			}
+			if !slices.ContainsFunc(statuses[j].IPs, func(reported string) bool {
+				return ip.Equal(net.ParseIP(reported))
+			}) {
+				return false, fmt.Sprintf("address %s of VPCAttachment %s not reported", address, name), nil
+			}
+		}
+	}
+	return true, "", nil
+}
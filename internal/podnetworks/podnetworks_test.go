@@ -0,0 +1,190 @@
+package podnetworks_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/podnetworks"
+)
+
+func TestParseVPCAttachmentNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"Empty", "", nil, false},
+		{"Single", "vpcattachment-a", []string{"vpcattachment-a"}, false},
+		{"CommaSeparated", "vpcattachment-a, vpcattachment-b", []string{"vpcattachment-a", "vpcattachment-b"}, false},
+		{"JSONList", `["vpcattachment-a","vpcattachment-b"]`, []string{"vpcattachment-a", "vpcattachment-b"}, false},
+		{"InvalidJSON", `["vpcattachment-a"`, nil, true},
+		{"EmptyName", "vpcattachment-a,,vpcattachment-b", nil, true},
+		{"Duplicate", "vpcattachment-a,vpcattachment-a", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := podnetworks.ParseVPCAttachmentNames(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVPCAttachmentNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVPCAttachmentNames() got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkSelectionElements(t *testing.T) {
+	vpcAttachment := func(name, interfaceName string) galacticv1alpha.VPCAttachment {
+		return galacticv1alpha.VPCAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: galacticv1alpha.VPCAttachmentSpec{
+				Interface: galacticv1alpha.VPCAttachmentInterface{Name: interfaceName},
+			},
+		}
+	}
+
+	vpcAttachments := []galacticv1alpha.VPCAttachment{
+		vpcAttachment("vpcattachment-a", "galactic0"),
+		vpcAttachment("vpcattachment-b", "galactic1"),
+	}
+	vpcAttachments[0].Spec.Interface.Addresses = []string{"10.1.1.1/24", "2001:10:1:1::1/64"}
+	vpcAttachments[0].Spec.Interface.MAC = "c2:b0:57:49:47:f1"
+	vpcAttachments[0].Spec.Interface.DefaultRoute = []string{"10.1.1.254"}
+	vpcAttachments[1].Status.Addresses = []string{"10.1.1.2/24"}
+	if err := podnetworks.ValidateInterfaceNames(vpcAttachments); err != nil {
+		t.Errorf("ValidateInterfaceNames() unexpected error = %v", err)
+	}
+	expected := []nadv1.NetworkSelectionElement{
+		{
+			Name:             "vpcattachment-a",
+			Namespace:        "default",
+			InterfaceRequest: "galactic0",
+			IPRequest:        []string{"10.1.1.1/24", "2001:10:1:1::1/64"},
+			MacRequest:       "c2:b0:57:49:47:f1",
+			GatewayRequest:   []net.IP{net.ParseIP("10.1.1.254")},
+		},
+		{
+			Name:             "vpcattachment-b",
+			Namespace:        "default",
+			InterfaceRequest: "galactic1",
+			IPRequest:        []string{"10.1.1.2/24"},
+		},
+	}
+	if got := podnetworks.NetworkSelectionElements(vpcAttachments); !reflect.DeepEqual(got, expected) {
+		t.Errorf("NetworkSelectionElements() got = %v, want = %v", got, expected)
+	}
+
+	vpcAttachments = append(vpcAttachments, vpcAttachment("vpcattachment-c", "galactic0"))
+	if err := podnetworks.ValidateInterfaceNames(vpcAttachments); err == nil {
+		t.Errorf("ValidateInterfaceNames() expected an error for a clashing interface name")
+	}
+}
+
+func TestParseNetworkSelectionElements(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []nadv1.NetworkSelectionElement
+		wantErr bool
+	}{
+		{"Empty", "", nil, false},
+		{"Name", "net-a", []nadv1.NetworkSelectionElement{{Name: "net-a"}}, false},
+		{"ShortForm", "net-a@net1, other/net-b@net2", []nadv1.NetworkSelectionElement{
+			{Name: "net-a", InterfaceRequest: "net1"},
+			{Name: "net-b", Namespace: "other", InterfaceRequest: "net2"},
+		}, false},
+		{"JSONForm", `[{"name":"net-a","interface":"net1","ips":["10.1.1.1/24"]}]`, []nadv1.NetworkSelectionElement{
+			{Name: "net-a", InterfaceRequest: "net1", IPRequest: []string{"10.1.1.1/24"}},
+		}, false},
+		{"InvalidJSON", `[{"name":"net-a"`, nil, true},
+		{"EmptyEntry", "net-a,,net-b", nil, true},
+		{"MissingName", "other/@net1", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := podnetworks.ParseNetworkSelectionElements(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNetworkSelectionElements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNetworkSelectionElements() got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagedNetworkSelectionElements(t *testing.T) {
+	elements := []nadv1.NetworkSelectionElement{
+		{Name: "net-a", InterfaceRequest: "galactic0"},
+		{Name: "sriov-net", InterfaceRequest: "net1"},
+		{Name: "net-b", Namespace: "default", InterfaceRequest: "galactic1"},
+		{Name: "net-a", Namespace: "other", InterfaceRequest: "net2"},
+	}
+	want := []nadv1.NetworkSelectionElement{
+		{Name: "net-a", InterfaceRequest: "galactic0"},
+		{Name: "net-b", Namespace: "default", InterfaceRequest: "galactic1"},
+	}
+
+	got := podnetworks.ManagedNetworkSelectionElements(elements, []string{"net-a", "net-b"}, "default")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ManagedNetworkSelectionElements() got = %v, want = %v", got, want)
+	}
+}
+
+func TestVPCInterfacesReady(t *testing.T) {
+	networks := `[{"name":"net-a","namespace":"default","interface":"galactic0","ips":["10.1.1.1/24","2001:10:1:1::1/64"]},` +
+		`{"name":"sriov-net","interface":"net1"}]`
+	tests := []struct {
+		name          string
+		networkStatus *string
+		want          bool
+		wantErr       bool
+	}{
+		{"NotReported", nil, false, false},
+		{"InterfaceMissing", ptr(`[{"name":"default/sriov-net","interface":"net1"}]`), false, false},
+		{"AddressMissing", ptr(`[{"name":"default/net-a","interface":"galactic0","ips":["10.1.1.1"]}]`), false, false},
+		{"WrongInterface", ptr(`[{"name":"default/net-a","interface":"net2","ips":["10.1.1.1","2001:10:1:1::1"]}]`), false, false},
+		{"Ready", ptr(`[{"name":"default/net-a","interface":"galactic0","ips":["10.1.1.1","2001:10:1:1:0::1"]}]`), true, false},
+		{"InvalidStatus", ptr(`[{"name":`), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Annotations: map[string]string{
+						galacticv1alpha.VPCAttachmentAnnotation: "net-a",
+						nadv1.NetworkAttachmentAnnot:            networks,
+					},
+				},
+			}
+			if tt.networkStatus != nil {
+				pod.Annotations[nadv1.NetworkStatusAnnot] = *tt.networkStatus
+			}
+			got, message, err := podnetworks.VPCInterfacesReady(pod)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VPCInterfacesReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VPCInterfacesReady() got = %v (%s), want = %v", got, message, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,143 @@
+package securitygroup
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+)
+
+// SelectorFunc returns the addresses of the VPCAttachments selected by a peer
+// of a rule, in CIDR notation.
+type SelectorFunc func(selector labels.Selector) []string
+
+// Firewall resolves the security groups of the VPCAttachment into the firewall
+// of its CNI configuration, or nil if it has none. Selectors select among the
+// VPCAttachments of the given VPCs. The names of missing security groups are
+// returned instead of a firewall, so that the traffic is never left open.
+func Firewall(ctx context.Context, c client.Reader, vpcAttachment *galacticv1alpha.VPCAttachment, vpcs []types.NamespacedName) (*cniconfig.Firewall, []string, error) {
+	if len(vpcAttachment.Spec.SecurityGroups) == 0 {
+		return nil, nil, nil
+	}
+
+	var securityGroups []galacticv1alpha.VPCSecurityGroup
+	var missing []string
+	for _, name := range vpcAttachment.Spec.SecurityGroups {
+		var securityGroup galacticv1alpha.VPCSecurityGroup
+		if err := c.Get(ctx, types.NamespacedName{Namespace: vpcAttachment.Namespace, Name: name}, &securityGroup); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, nil, err
+			}
+			missing = append(missing, name)
+			continue
+		}
+		securityGroups = append(securityGroups, securityGroup)
+	}
+	if len(missing) > 0 {
+		return nil, missing, nil
+	}
+
+	var vpcAttachments galacticv1alpha.VPCAttachmentList
+	if err := c.List(ctx, &vpcAttachments, client.InNamespace(vpcAttachment.Namespace)); err != nil {
+		return nil, nil, err
+	}
+	selected := func(selector labels.Selector) []string {
+		var addresses []string
+		for _, other := range vpcAttachments.Items {
+			vpc := types.NamespacedName{Namespace: other.Spec.VPC.Namespace, Name: other.Spec.VPC.Name}
+			if other.DeletionTimestamp.IsZero() && slices.Contains(vpcs, vpc) && selector.Matches(labels.Set(other.Labels)) {
+				addresses = append(addresses, cniconfig.InterfaceAddresses(other)...)
+			}
+		}
+		return addresses
+	}
+
+	firewall := &cniconfig.Firewall{
+		Ingress: []cniconfig.FirewallRule{},
+		Egress:  []cniconfig.FirewallRule{},
+	}
+	for _, securityGroup := range securityGroups {
+		ingress, err := Rules(securityGroup.Spec.Ingress, selected)
+		if err != nil {
+			return nil, nil, fmt.Errorf("VPCSecurityGroup %s: %w", securityGroup.Name, err)
+		}
+		egress, err := Rules(securityGroup.Spec.Egress, selected)
+		if err != nil {
+			return nil, nil, fmt.Errorf("VPCSecurityGroup %s: %w", securityGroup.Name, err)
+		}
+		firewall.Ingress = append(firewall.Ingress, ingress...)
+		firewall.Egress = append(firewall.Egress, egress...)
+	}
+	return firewall, nil, nil
+}
+
+// Rules renders the rules of a security group. The networks of a rule are
+// sorted and deduplicated, and the VPCAttachments selected by its peers
+// contribute their addresses as host networks. A rule whose peers select no
+// addresses is left out, since it allows no traffic.
+func Rules(rules []galacticv1alpha.VPCSecurityGroupRule, selected SelectorFunc) ([]cniconfig.FirewallRule, error) {
+	rendered := make([]cniconfig.FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		var networks []string
+		for _, peer := range rule.Peers {
+			switch {
+			case peer.CIDR != "":
+				_, network, err := net.ParseCIDR(peer.CIDR)
+				if err != nil {
+					return nil, err
+				}
+				networks = append(networks, network.String())
+			case peer.VPCAttachmentSelector != nil:
+				selector, err := metav1.LabelSelectorAsSelector(peer.VPCAttachmentSelector)
+				if err != nil {
+					return nil, err
+				}
+				for _, address := range selected(selector) {
+					ip, _, err := net.ParseCIDR(address)
+					if err != nil {
+						continue
+					}
+					networks = append(networks, hostNetwork(ip))
+				}
+			}
+		}
+		if len(rule.Peers) > 0 && len(networks) == 0 {
+			continue
+		}
+		slices.Sort(networks)
+
+		var ports []string
+		for _, port := range rule.Ports {
+			if port.EndPort != 0 && port.EndPort != port.Port {
+				ports = append(ports, fmt.Sprintf("%d-%d", port.Port, port.EndPort))
+			} else {
+				ports = append(ports, fmt.Sprint(port.Port))
+			}
+		}
+
+		rendered = append(rendered, cniconfig.FirewallRule{
+			Networks: slices.Compact(networks),
+			Protocol: strings.ToLower(string(rule.Protocol)),
+			Ports:    ports,
+		})
+	}
+	return rendered, nil
+}
+
+func hostNetwork(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}).String()
+}
@@ -0,0 +1,97 @@
+package securitygroup_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+	"github.com/datum-cloud/galactic-operator/internal/securitygroup"
+)
+
+func vpcAttachment(name, vpcName string, labels map[string]string, addresses ...string) *galacticv1alpha.VPCAttachment {
+	return &galacticv1alpha.VPCAttachment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec: galacticv1alpha.VPCAttachmentSpec{
+			VPC:       corev1.ObjectReference{Name: vpcName, Namespace: "default"},
+			Interface: galacticv1alpha.VPCAttachmentInterface{Name: "galactic0", Addresses: addresses},
+		},
+	}
+}
+
+func TestFirewall(t *testing.T) {
+	web := &galacticv1alpha.VPCSecurityGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: galacticv1alpha.VPCSecurityGroupSpec{
+			Ingress: []galacticv1alpha.VPCSecurityGroupRule{
+				{
+					Peers: []galacticv1alpha.VPCSecurityGroupPeer{
+						{VPCAttachmentSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "frontend"}}},
+						{CIDR: "10.9.0.0/16"},
+					},
+					Protocol: galacticv1alpha.VPCSecurityGroupProtocolTCP,
+					Ports:    []galacticv1alpha.VPCSecurityGroupPort{{Port: 443}, {Port: 8000, EndPort: 8080}},
+				},
+				// Selects nothing, so it must not open the traffic to everyone
+				{
+					Peers: []galacticv1alpha.VPCSecurityGroupPeer{
+						{VPCAttachmentSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "admin"}}},
+					},
+				},
+			},
+		},
+	}
+	egress := &galacticv1alpha.VPCSecurityGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "egress", Namespace: "default"},
+		Spec: galacticv1alpha.VPCSecurityGroupSpec{
+			Egress: []galacticv1alpha.VPCSecurityGroupRule{{}},
+		},
+	}
+
+	backend := vpcAttachment("backend", "vpc-a", nil, "10.1.1.1/24")
+	backend.Spec.SecurityGroups = []string{"web", "egress"}
+	frontend := vpcAttachment("frontend", "vpc-a", map[string]string{"role": "frontend"}, "10.1.1.2/24", "2001:10:1:1::2/64")
+	peered := vpcAttachment("frontend-peered", "vpc-b", map[string]string{"role": "frontend"}, "10.2.1.2/24")
+	unreachable := vpcAttachment("frontend-other", "vpc-c", map[string]string{"role": "frontend"}, "10.3.1.2/24")
+
+	scheme := runtime.NewScheme()
+	if err := galacticv1alpha.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(web, egress, backend, frontend, peered, unreachable).Build()
+	vpcs := []types.NamespacedName{{Namespace: "default", Name: "vpc-a"}, {Namespace: "default", Name: "vpc-b"}}
+
+	firewall, missing, err := securitygroup.Firewall(context.Background(), c, backend, vpcs)
+	if err != nil || len(missing) > 0 {
+		t.Fatalf("Firewall() error = %v, missing = %v", err, missing)
+	}
+	expected := &cniconfig.Firewall{
+		Ingress: []cniconfig.FirewallRule{{
+			Networks: []string{"10.1.1.2/32", "10.2.1.2/32", "10.9.0.0/16", "2001:10:1:1::2/128"},
+			Protocol: "tcp",
+			Ports:    []string{"443", "8000-8080"},
+		}},
+		Egress: []cniconfig.FirewallRule{{}},
+	}
+	if !reflect.DeepEqual(expected, firewall) {
+		t.Errorf("Firewall() got = %+v, want = %+v", firewall, expected)
+	}
+
+	if firewall, _, _ := securitygroup.Firewall(context.Background(), c, frontend, vpcs); firewall != nil {
+		t.Errorf("Firewall() got = %+v for a VPCAttachment without security groups", firewall)
+	}
+
+	backend.Spec.SecurityGroups = append(backend.Spec.SecurityGroups, "missing")
+	firewall, missing, err = securitygroup.Firewall(context.Background(), c, backend, vpcs)
+	if err != nil || firewall != nil || !reflect.DeepEqual(missing, []string{"missing"}) {
+		t.Errorf("Firewall() got = %+v, missing = %v, error = %v", firewall, missing, err)
+	}
+}
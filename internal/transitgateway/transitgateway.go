@@ -0,0 +1,168 @@
+package transitgateway
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/peering"
+)
+
+// Reachable is a VPC reachable through a route table of a TransitGateway.
+type Reachable struct {
+	VPC            galacticv1alpha.VPC
+	TransitGateway string
+	RouteTable     string
+}
+
+// Overlap describes two VPCs with overlapping networks, see FindOverlap.
+type Overlap struct {
+	VPC, OtherVPC         types.NamespacedName
+	Network, OtherNetwork string
+}
+
+// Associated returns the VPCs associated with the TransitGateway, sorted by
+// namespace and name. VPCs being deleted are left out.
+func Associated(transitGateway string, vpcs []galacticv1alpha.VPC) []galacticv1alpha.VPC {
+	var associated []galacticv1alpha.VPC
+	for _, vpc := range vpcs {
+		if vpc.DeletionTimestamp.IsZero() && vpc.Spec.TransitGateway != nil && vpc.Spec.TransitGateway.Name == transitGateway {
+			associated = append(associated, vpc)
+		}
+	}
+	slices.SortFunc(associated, func(a, b galacticv1alpha.VPC) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return associated
+}
+
+// RouteTable returns the route table of the TransitGateway with the given
+// name, or nil if there is none.
+func RouteTable(transitGateway *galacticv1alpha.TransitGateway, name string) *galacticv1alpha.TransitGatewayRouteTable {
+	i := slices.IndexFunc(transitGateway.Spec.RouteTables, func(routeTable galacticv1alpha.TransitGatewayRouteTable) bool {
+		return routeTable.Name == name
+	})
+	if i < 0 {
+		return nil
+	}
+	return &transitGateway.Spec.RouteTables[i]
+}
+
+// Permitted reports whether VPCs of the namespace may associate with the route
+// table, that is whether its namespace selector selects the namespace.
+func Permitted(ctx context.Context, c client.Reader, routeTable *galacticv1alpha.TransitGatewayRouteTable, namespace string) (bool, error) {
+	if routeTable.NamespaceSelector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(routeTable.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector of route table %s: %w", routeTable.Name, err)
+	}
+	if selector.Empty() {
+		return true, nil
+	}
+	var ns corev1.Namespace
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// Propagated returns the VPCs of associated whose networks are propagated into
+// the route table, that is the VPCs associated with a route table it
+// propagates from.
+func Propagated(routeTable *galacticv1alpha.TransitGatewayRouteTable, associated []galacticv1alpha.VPC) []galacticv1alpha.VPC {
+	var propagated []galacticv1alpha.VPC
+	for _, vpc := range associated {
+		if slices.Contains(routeTable.PropagateFrom, vpc.Spec.TransitGateway.RouteTable) {
+			propagated = append(propagated, vpc)
+		}
+	}
+	return propagated
+}
+
+// FindOverlap returns the first pair of VPCs with overlapping networks.
+func FindOverlap(vpcs []galacticv1alpha.VPC) (Overlap, bool) {
+	for i := range vpcs {
+		for j := 0; j < i; j++ {
+			if network, otherNetwork, overlap := peering.OverlappingNetworks(vpcs[j].Spec.Networks, vpcs[i].Spec.Networks); overlap {
+				return Overlap{
+					VPC:          client.ObjectKeyFromObject(&vpcs[j]),
+					OtherVPC:     client.ObjectKeyFromObject(&vpcs[i]),
+					Network:      network,
+					OtherNetwork: otherNetwork,
+				}, true
+			}
+		}
+	}
+	return Overlap{}, false
+}
+
+// ReachableVPCs returns the ready VPCs reachable from the VPC through the route
+// table of its TransitGateway. Only VPCs permitted to associate with their
+// route tables reach or are reachable. VPCs whose networks overlap with the
+// networks of the VPC or of a VPC reachable before them are left out.
+func ReachableVPCs(ctx context.Context, c client.Reader, vpc *galacticv1alpha.VPC) ([]Reachable, error) {
+	association := vpc.Spec.TransitGateway
+	if association == nil {
+		return nil, nil
+	}
+	var transitGateway galacticv1alpha.TransitGateway
+	if err := c.Get(ctx, types.NamespacedName{Name: association.Name}, &transitGateway); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	routeTable := RouteTable(&transitGateway, association.RouteTable)
+	if routeTable == nil {
+		return nil, nil
+	}
+	if permitted, err := Permitted(ctx, c, routeTable, vpc.Namespace); err != nil || !permitted {
+		return nil, err
+	}
+
+	var vpcs galacticv1alpha.VPCList
+	if err := c.List(ctx, &vpcs); err != nil {
+		return nil, err
+	}
+	var reachable []Reachable
+	networks := slices.Clone(vpc.Spec.Networks)
+	for _, other := range Propagated(routeTable, Associated(transitGateway.Name, vpcs.Items)) {
+		if client.ObjectKeyFromObject(&other) == client.ObjectKeyFromObject(vpc) || !other.Status.Ready || other.Status.Identifier == "" {
+			continue
+		}
+		otherRouteTable := RouteTable(&transitGateway, other.Spec.TransitGateway.RouteTable)
+		if otherRouteTable == nil {
+			continue
+		}
+		if permitted, err := Permitted(ctx, c, otherRouteTable, other.Namespace); err != nil {
+			return nil, err
+		} else if !permitted {
+			continue
+		}
+		if _, _, overlap := peering.OverlappingNetworks(other.Spec.Networks, networks); overlap {
+			continue
+		}
+		networks = append(networks, other.Spec.Networks...)
+		reachable = append(reachable, Reachable{
+			VPC:            other,
+			TransitGateway: transitGateway.Name,
+			RouteTable:     routeTable.Name,
+		})
+	}
+	return reachable, nil
+}
@@ -0,0 +1,161 @@
+package transitgateway_test
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/transitgateway"
+)
+
+func vpc(namespace, name, routeTable string, networks ...string) *galacticv1alpha.VPC {
+	return &galacticv1alpha.VPC{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: galacticv1alpha.VPCSpec{
+			Networks:       networks,
+			TransitGateway: &galacticv1alpha.VPCTransitGatewayAssociation{Name: "hub", RouteTable: routeTable},
+		},
+		Status: galacticv1alpha.VPCStatus{Ready: true, Identifier: "1"},
+	}
+}
+
+func TestReachableVPCs(t *testing.T) {
+	transitGateway := &galacticv1alpha.TransitGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub"},
+		Spec: galacticv1alpha.TransitGatewaySpec{
+			RouteTables: []galacticv1alpha.TransitGatewayRouteTable{
+				// Spokes reach the shared services but not each other
+				{Name: "spokes", PropagateFrom: []string{"shared"}, NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"hub": "spoke"},
+				}},
+				{Name: "shared", PropagateFrom: []string{"spokes", "shared"}, NamespaceSelector: &metav1.LabelSelector{}},
+			},
+		},
+	}
+	spokeA := vpc("team-a", "spoke", "spokes", "10.1.0.0/16")
+	spokeB := vpc("team-b", "spoke", "spokes", "10.2.0.0/16")
+	spokeC := vpc("team-c", "spoke", "spokes", "10.2.1.0/24")
+	// team-d never got to associate with the spokes route table
+	spokeD := vpc("team-d", "spoke", "spokes", "10.4.0.0/16")
+	services := vpc("shared", "services", "shared", "10.100.0.0/16")
+	dns := vpc("shared", "dns", "shared", "10.101.0.0/16")
+	unassociated := &galacticv1alpha.VPC{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"},
+		Spec:       galacticv1alpha.VPCSpec{Networks: []string{"10.3.0.0/16"}},
+	}
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	scheme := runtime.NewScheme()
+	if err := galacticv1alpha.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(transitGateway, spokeA, spokeB, spokeC, spokeD, services, dns, unassociated,
+			namespace("team-a", map[string]string{"hub": "spoke"}),
+			namespace("team-b", map[string]string{"hub": "spoke"}),
+			namespace("team-c", map[string]string{"hub": "spoke"}),
+			namespace("team-d", nil),
+			namespace("shared", nil)).Build()
+
+	tests := []struct {
+		name string
+		vpc  *galacticv1alpha.VPC
+		want []string
+	}{
+		{"Spoke", spokeA, []string{"shared/dns", "shared/services"}},
+		// team-c/spoke overlaps with team-b/spoke, which is reachable first
+		{"SharedServices", services, []string{"shared/dns", "team-a/spoke", "team-b/spoke"}},
+		{"Unassociated", unassociated, nil},
+		{"NotPermitted", spokeD, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reachable, err := transitgateway.ReachableVPCs(context.Background(), c, tt.vpc)
+			if err != nil {
+				t.Fatalf("ReachableVPCs() error = %v", err)
+			}
+			var got []string
+			for _, r := range reachable {
+				got = append(got, r.VPC.Namespace+"/"+r.VPC.Name)
+				if r.TransitGateway != "hub" || r.RouteTable != tt.vpc.Spec.TransitGateway.RouteTable {
+					t.Errorf("ReachableVPCs() got route through %s/%s", r.TransitGateway, r.RouteTable)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ReachableVPCs() got = %v, want = %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ReachableVPCs() got = %v, want = %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindOverlap(t *testing.T) {
+	vpcs := []galacticv1alpha.VPC{
+		*vpc("team-a", "spoke", "spokes", "10.1.0.0/16"),
+		*vpc("team-b", "spoke", "spokes", "10.2.0.0/16", "2001:10:2::/48"),
+	}
+	if _, overlap := transitgateway.FindOverlap(vpcs); overlap {
+		t.Errorf("FindOverlap() reported an overlap of disjoint VPCs")
+	}
+
+	vpcs = append(vpcs, *vpc("team-c", "spoke", "spokes", "2001:10:2:1::/64"))
+	overlap, ok := transitgateway.FindOverlap(vpcs)
+	if !ok || overlap.VPC.Namespace != "team-b" || overlap.OtherVPC.Namespace != "team-c" {
+		t.Errorf("FindOverlap() got = %+v, %v", overlap, ok)
+	}
+}
+
+func TestPermitted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"hub": "spoke"}},
+	}).Build()
+
+	spokes := &metav1.LabelSelector{MatchLabels: map[string]string{"hub": "spoke"}}
+	tests := []struct {
+		name          string
+		selector      *metav1.LabelSelector
+		namespace     string
+		wantPermitted bool
+		wantError     bool
+	}{
+		{"NoSelector", nil, "team-a", false, false},
+		{"EmptySelector", &metav1.LabelSelector{}, "team-b", true, false},
+		{"Selected", spokes, "team-a", true, false},
+		{"NotSelected", &metav1.LabelSelector{MatchLabels: map[string]string{"hub": "shared"}}, "team-a", false, false},
+		{"MissingNamespace", spokes, "team-b", false, false},
+		{"InvalidSelector", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "hub", Operator: "Near"},
+		}}, "team-a", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routeTable := &galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", NamespaceSelector: tt.selector}
+			permitted, err := transitgateway.Permitted(context.Background(), c, routeTable, tt.namespace)
+			if (err != nil) != tt.wantError {
+				t.Errorf("Permitted() error = %v, wantError = %v", err, tt.wantError)
+			}
+			if permitted != tt.wantPermitted {
+				t.Errorf("Permitted() got = %v, want = %v", permitted, tt.wantPermitted)
+			}
+		})
+	}
+}
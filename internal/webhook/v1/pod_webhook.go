@@ -2,12 +2,18 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -15,6 +21,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	"github.com/datum-cloud/galactic-operator/internal/grant"
+	"github.com/datum-cloud/galactic-operator/internal/podnetworks"
 )
 
 const PodAnnotationMultusNetworks = "k8s.v1.cni.cncf.io/networks"
@@ -35,7 +43,7 @@ func SetupPodWebhookWithManager(mgr ctrl.Manager) error {
 		Complete()
 }
 
-// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create;update,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=fail,sideEffects=NoneOnDryRun,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1
 
 type PodCustomDefaulter struct {
 	client.Client
@@ -51,19 +59,91 @@ func (d *PodCustomDefaulter) Default(ctx context.Context, obj runtime.Object) er
 		return fmt.Errorf("expected an Pod object but got %T", obj)
 	}
 
+	var stamped []galacticv1alpha.VPCAttachment
+	if _, exists := pod.Annotations[galacticv1alpha.VPCAttachmentTemplateAnnotation]; exists {
+		var err error
+		if stamped, err = d.stampVPCAttachments(ctx, pod); err != nil {
+			return err
+		}
+	}
+
 	if _, exists := pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation]; !exists {
 		return nil
 	}
 
-	vpcAttachment, err := vpcAttachmentByName(d.Client, ctx, pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation], pod.GetNamespace())
+	vpcAttachments, err := vpcAttachmentsForPod(d.Client, ctx, pod, stamped)
+	if err != nil {
+		return err
+	}
+	// Networks requested by the user besides the VPCAttachments are preserved
+	existing, err := podnetworks.ParseNetworkSelectionElements(pod.Annotations[PodAnnotationMultusNetworks])
+	if err != nil {
+		return fmt.Errorf("unable to parse annotation %s: %w", PodAnnotationMultusNetworks, err)
+	}
+	elements, err := podnetworks.MergeNetworkSelectionElements(existing,
+		podnetworks.NetworkSelectionElements(vpcAttachments), pod.GetNamespace())
+	if err != nil {
+		return err
+	}
+	networks, err := json.Marshal(elements)
 	if err != nil {
 		return err
 	}
-	pod.Annotations[PodAnnotationMultusNetworks] = fmt.Sprintf("%s@%s", vpcAttachment.Name, vpcAttachment.Spec.Interface.Name)
+	pod.Annotations[PodAnnotationMultusNetworks] = string(networks)
+
+	// The operator only caches the Pods attached to VPCAttachments
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[galacticv1alpha.VPCAttachedPodLabel] = "true"
+
+	// The Pod becomes ready only once its VPC interfaces are up
+	if !slices.ContainsFunc(pod.Spec.ReadinessGates, func(gate corev1.PodReadinessGate) bool {
+		return gate.ConditionType == galacticv1alpha.PodConditionVPCReady
+	}) {
+		pod.Spec.ReadinessGates = append(pod.Spec.ReadinessGates, corev1.PodReadinessGate{
+			ConditionType: galacticv1alpha.PodConditionVPCReady,
+		})
+	}
 
 	return nil
 }
 
+// stampVPCAttachments gives the Pod its own VPCAttachments from the templates
+// it lists and adds them to its VPCAttachment annotation. Dry runs only
+// return the VPCAttachments that would be created.
+func (d *PodCustomDefaulter) stampVPCAttachments(ctx context.Context, pod *corev1.Pod) ([]galacticv1alpha.VPCAttachment, error) {
+	// The stamped VPCAttachments are named after the Pod. Generated names are
+	// only chosen by the API server after admission, and may still change if
+	// they collide.
+	if pod.Name == "" {
+		return nil, fmt.Errorf("a Pod using a VPCAttachmentTemplate needs a name, generateName is not supported")
+	}
+
+	stamped, err := stampVPCAttachments(d.Client, ctx, pod, !isDryRun(ctx))
+	if err != nil {
+		return nil, err
+	}
+	names, err := podnetworks.VPCAttachmentNames(pod.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	for _, vpcAttachment := range stamped {
+		if !slices.Contains(names, vpcAttachment.Name) {
+			names = append(names, vpcAttachment.Name)
+		}
+	}
+	pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation] = strings.Join(names, ",")
+	return stamped, nil
+}
+
+// isDryRun reports whether the admission request in ctx is a dry run, which
+// must not have side effects.
+func isDryRun(ctx context.Context) bool {
+	req, err := admission.RequestFromContext(ctx)
+	return err == nil && req.DryRun != nil && *req.DryRun
+}
+
 // +kubebuilder:webhook:path=/validate--v1-pod,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create;update,versions=v1,name=vpod-v1.kb.io,admissionReviewVersions=v1
 
 type PodCustomValidator struct {
@@ -83,20 +163,42 @@ func (v *PodCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Obj
 		return nil, nil
 	}
 
-	if _, err := vpcAttachmentByName(v.Client, ctx, pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation], pod.GetNamespace()); err != nil {
+	// The VPCAttachments stamped out by the defaulter may not be cached yet,
+	// and do not exist at all for dry runs
+	var stamped []galacticv1alpha.VPCAttachment
+	if _, exists := pod.Annotations[galacticv1alpha.VPCAttachmentTemplateAnnotation]; exists {
+		var err error
+		if stamped, err = stampVPCAttachments(v.Client, ctx, pod, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := vpcAttachmentsForPod(v.Client, ctx, pod, stamped); err != nil {
 		return nil, err
 	}
 
 	return nil, nil
 }
 
-func (v *PodCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
-	_, ok := newObj.(*corev1.Pod)
+func (v *PodCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a Pod object for the oldObj but got %T", oldObj)
+	}
+	pod, ok := newObj.(*corev1.Pod)
 	if !ok {
 		return nil, fmt.Errorf("expected a Pod object for the newObj but got %T", newObj)
 	}
 
-	return nil, nil
+	if allErrs := validatePodNetworksUpdate(oldPod, pod); len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(), pod.Name, allErrs)
+	}
+
+	if _, exists := pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation]; !exists {
+		return nil, nil
+	}
+
+	return vpcAttachmentWarnings(v.Client, ctx, pod)
 }
 
 func (v *PodCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
@@ -108,16 +210,145 @@ func (v *PodCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Obj
 	return nil, nil
 }
 
-func vpcAttachmentByName(k8sClient client.Client, ctx context.Context, name, namespace string) (*galacticv1alpha.VPCAttachment, error) {
+// validatePodNetworksUpdate rejects changes to the Galactic annotations and
+// label of an existing Pod and to the Multus network selection elements
+// generated from them, as the Pod is already attached to its VPCAttachments.
+func validatePodNetworksUpdate(oldPod, pod *corev1.Pod) field.ErrorList {
+	var allErrs field.ErrorList
+	annotationsPath := field.NewPath("metadata", "annotations")
+
+	for _, key := range []string{galacticv1alpha.VPCAttachmentAnnotation, galacticv1alpha.VPCAttachmentTemplateAnnotation} {
+		oldValue, oldExists := oldPod.Annotations[key]
+		value, exists := pod.Annotations[key]
+		if oldValue != value || oldExists != exists {
+			allErrs = append(allErrs, field.Forbidden(annotationsPath.Key(key), "cannot be changed on an existing Pod"))
+		}
+	}
+
+	// Without the label the operator no longer sees the Pod
+	if oldValue, oldExists := oldPod.Labels[galacticv1alpha.VPCAttachedPodLabel]; oldExists {
+		if value, exists := pod.Labels[galacticv1alpha.VPCAttachedPodLabel]; !exists || value != oldValue {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "labels").Key(galacticv1alpha.VPCAttachedPodLabel),
+				"cannot be changed on an existing Pod"))
+		}
+	}
+
+	names, err := podnetworks.VPCAttachmentNames(oldPod.Annotations)
+	if err != nil || len(names) == 0 {
+		return allErrs
+	}
+	multusPath := annotationsPath.Key(PodAnnotationMultusNetworks)
+	oldElements, err := podnetworks.ParseNetworkSelectionElements(oldPod.Annotations[PodAnnotationMultusNetworks])
+	if err != nil {
+		return allErrs
+	}
+	elements, err := podnetworks.ParseNetworkSelectionElements(pod.Annotations[PodAnnotationMultusNetworks])
+	if err != nil {
+		return append(allErrs, field.Invalid(multusPath, pod.Annotations[PodAnnotationMultusNetworks], err.Error()))
+	}
+	if !equality.Semantic.DeepEqual(
+		podnetworks.ManagedNetworkSelectionElements(oldElements, names, oldPod.Namespace),
+		podnetworks.ManagedNetworkSelectionElements(elements, names, pod.Namespace)) {
+		allErrs = append(allErrs, field.Forbidden(multusPath, "networks of the VPCAttachments cannot be changed on an existing Pod"))
+	}
+	return allErrs
+}
+
+// vpcAttachmentWarnings warns about VPCAttachments of the Pod that have been
+// removed or are no longer ready since its admission.
+func vpcAttachmentWarnings(k8sClient client.Client, ctx context.Context, pod *corev1.Pod) (admission.Warnings, error) {
+	names, err := podnetworks.VPCAttachmentNames(pod.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings admission.Warnings
+	for _, name := range names {
+		var vpcAttachment galacticv1alpha.VPCAttachment
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: pod.Namespace}, &vpcAttachment)
+		switch {
+		case apierrors.IsNotFound(err):
+			warnings = append(warnings, fmt.Sprintf("VPCAttachment %s/%s no longer exists", pod.Namespace, name))
+		case err != nil:
+			return nil, err
+		case !vpcAttachment.Status.Ready:
+			warnings = append(warnings, fmt.Sprintf("VPCAttachment %s/%s is not ready", pod.Namespace, name))
+		}
+	}
+	return warnings, nil
+}
+
+// vpcAttachmentsForPod resolves the VPCAttachments listed in the annotation of
+// the Pod, taking those stamped out for it from stamped, and checks that they
+// can be attached together.
+func vpcAttachmentsForPod(k8sClient client.Client, ctx context.Context, pod *corev1.Pod, stamped []galacticv1alpha.VPCAttachment) ([]galacticv1alpha.VPCAttachment, error) {
+	names, err := podnetworks.VPCAttachmentNames(pod.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("annotation %s does not list any VPCAttachment", galacticv1alpha.VPCAttachmentAnnotation)
+	}
+
+	vpcAttachments := make([]galacticv1alpha.VPCAttachment, 0, len(names))
+	for _, name := range names {
+		vpcAttachment, err := vpcAttachmentByName(k8sClient, ctx, name, pod, stamped)
+		if err != nil {
+			return nil, err
+		}
+		if vpcAttachment.Spec.BindingMode == galacticv1alpha.VPCAttachmentBindingModeExclusive {
+			if err := validateExclusiveBinding(k8sClient, ctx, pod, vpcAttachment); err != nil {
+				return nil, err
+			}
+		}
+		vpcAttachments = append(vpcAttachments, *vpcAttachment)
+	}
+	if err := podnetworks.ValidateInterfaceNames(vpcAttachments); err != nil {
+		return nil, err
+	}
+	return vpcAttachments, nil
+}
+
+// validateExclusiveBinding rejects the Pod if another active Pod already uses
+// the Exclusive VPCAttachment.
+func validateExclusiveBinding(k8sClient client.Client, ctx context.Context, pod *corev1.Pod, vpcAttachment *galacticv1alpha.VPCAttachment) error {
+	var pods corev1.PodList
+	if err := k8sClient.List(ctx, &pods, client.InNamespace(vpcAttachment.Namespace)); err != nil {
+		return err
+	}
+	for _, other := range podnetworks.ActivePodsUsingVPCAttachment(pods.Items, vpcAttachment.Name) {
+		if other.Name != pod.Name {
+			return fmt.Errorf("VPCAttachment %s/%s is bound exclusively to Pod %s",
+				vpcAttachment.Namespace, vpcAttachment.Name, other.Name)
+		}
+	}
+	return nil
+}
+
+func vpcAttachmentByName(k8sClient client.Client, ctx context.Context, name string, pod *corev1.Pod, stamped []galacticv1alpha.VPCAttachment) (*galacticv1alpha.VPCAttachment, error) {
+	namespace := pod.GetNamespace()
 	typeNamespacedName := types.NamespacedName{
 		Name:      name,
 		Namespace: namespace,
 	}
 	var vpcAttachment galacticv1alpha.VPCAttachment
-	if err := k8sClient.Get(ctx, typeNamespacedName, &vpcAttachment); err != nil {
+	if i := slices.IndexFunc(stamped, func(vpcAttachment galacticv1alpha.VPCAttachment) bool {
+		return vpcAttachment.Name == name
+	}); i >= 0 {
+		vpcAttachment = stamped[i]
+	} else if err := k8sClient.Get(ctx, typeNamespacedName, &vpcAttachment); err != nil {
 		return nil, err
 	}
-	if !vpcAttachment.Status.Ready {
+	permitted, err := grant.VPCAttachmentPermitted(ctx, k8sClient, namespace, vpcAttachment.Spec.VPC)
+	if err != nil {
+		return nil, err
+	}
+	if !permitted {
+		return nil, fmt.Errorf("VPCAttachment %s/%s references VPC %s/%s which no VPCAttachmentGrant permits",
+			namespace, name, vpcAttachment.Spec.VPC.Namespace, vpcAttachment.Spec.VPC.Name)
+	}
+	// VPCAttachments stamped out for the Pod become ready only after its admission
+	if !vpcAttachment.Status.Ready && vpcAttachment.Labels[galacticv1alpha.VPCAttachmentPodLabel] != pod.GetName() {
 		return nil, fmt.Errorf("VPCAttachment %s/%s is not ready", namespace, name)
 	}
 	return &vpcAttachment, nil
@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Names of the pod webhooks within the webhook configurations
+const (
+	PodMutatingWebhookName   = "mpod-v1.kb.io"
+	PodValidatingWebhookName = "vpod-v1.kb.io"
+)
+
+// Names of the webhook configurations registering the pod webhooks, as
+// deployed by config/default. The RBAC below only grants access to these, so
+// they are fixed; a different kustomize namePrefix needs the RBAC patched to
+// match.
+const (
+	PodMutatingWebhookConfigurationName   = "galactic-operator-mutating-webhook-configuration"
+	PodValidatingWebhookConfigurationName = "galactic-operator-validating-webhook-configuration"
+)
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=get;list;watch;update,resourceNames=galactic-operator-mutating-webhook-configuration;galactic-operator-validating-webhook-configuration
+
+// PodWebhookRegistration selects the Pods sent to the pod webhooks and what
+// happens to them when the webhooks cannot be reached.
+type PodWebhookRegistration struct {
+	// Names of the webhook configurations registering the pod webhooks
+	MutatingWebhookConfiguration   string
+	ValidatingWebhookConfiguration string
+
+	// NamespaceSelector and ObjectSelector restrict the pod webhooks to the
+	// matching namespaces and Pods, nil leaves the selector of the webhook
+	// configuration as it is
+	NamespaceSelector *metav1.LabelSelector
+	ObjectSelector    *metav1.LabelSelector
+
+	FailurePolicy admissionregistrationv1.FailurePolicyType
+}
+
+// ParsePodWebhookRegistration builds a PodWebhookRegistration of the deployed
+// webhook configurations from the label selectors and the failure policy
+// given on the command line.
+func ParsePodWebhookRegistration(namespaceSelector, objectSelector, failurePolicy string) (*PodWebhookRegistration, error) {
+	registration := &PodWebhookRegistration{
+		MutatingWebhookConfiguration:   PodMutatingWebhookConfigurationName,
+		ValidatingWebhookConfiguration: PodValidatingWebhookConfigurationName,
+		FailurePolicy:                  admissionregistrationv1.FailurePolicyType(failurePolicy),
+	}
+	switch registration.FailurePolicy {
+	case admissionregistrationv1.Fail, admissionregistrationv1.Ignore:
+	default:
+		return nil, fmt.Errorf("unsupported failure policy %q, must be %s or %s",
+			failurePolicy, admissionregistrationv1.Fail, admissionregistrationv1.Ignore)
+	}
+
+	var err error
+	if namespaceSelector != "" {
+		if registration.NamespaceSelector, err = metav1.ParseToLabelSelector(namespaceSelector); err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+	}
+	if objectSelector != "" {
+		if registration.ObjectSelector, err = metav1.ParseToLabelSelector(objectSelector); err != nil {
+			return nil, fmt.Errorf("invalid object selector: %w", err)
+		}
+	}
+	return registration, nil
+}
+
+// CacheByObject restricts the cached webhook configurations to those
+// registering the pod webhooks, which are the only ones the operator may read.
+func (r *PodWebhookRegistration) CacheByObject() map[client.Object]cache.ByObject {
+	return map[client.Object]cache.ByObject{
+		&admissionregistrationv1.MutatingWebhookConfiguration{}: {
+			Field: fields.OneTermEqualSelector("metadata.name", r.MutatingWebhookConfiguration),
+		},
+		&admissionregistrationv1.ValidatingWebhookConfiguration{}: {
+			Field: fields.OneTermEqualSelector("metadata.name", r.ValidatingWebhookConfiguration),
+		},
+	}
+}
+
+// SetupPodWebhookRegistrationWithManager keeps the pod webhooks in line with
+// the registration, reapplying it whenever the webhook configurations change,
+// e.g. because they are redeployed. The manager cache must be restricted to
+// the webhook configurations with CacheByObject.
+func SetupPodWebhookRegistrationWithManager(mgr ctrl.Manager, registration *PodWebhookRegistration) error {
+	request := func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: registration.MutatingWebhookConfiguration}}}
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("podwebhookregistration").
+		Watches(&admissionregistrationv1.MutatingWebhookConfiguration{}, handler.EnqueueRequestsFromMapFunc(request)).
+		Watches(&admissionregistrationv1.ValidatingWebhookConfiguration{}, handler.EnqueueRequestsFromMapFunc(request)).
+		Complete(reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, registration.Apply(ctx, mgr.GetAPIReader(), mgr.GetClient())
+		}))
+}
+
+// Apply updates the pod webhooks of the webhook configurations, retrying on
+// conflicts with other writers such as the CA injector. Configurations that do
+// not exist yet are skipped, they are registered once they are created.
+func (r *PodWebhookRegistration) Apply(ctx context.Context, reader client.Reader, writer client.Writer) error {
+	log := logf.FromContext(ctx)
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var configuration admissionregistrationv1.MutatingWebhookConfiguration
+		if err := reader.Get(ctx, types.NamespacedName{Name: r.MutatingWebhookConfiguration}, &configuration); err != nil {
+			return err
+		}
+		original := configuration.DeepCopy()
+		found := false
+		for i := range configuration.Webhooks {
+			if configuration.Webhooks[i].Name == PodMutatingWebhookName {
+				r.applyTo(&configuration.Webhooks[i].NamespaceSelector, &configuration.Webhooks[i].ObjectSelector,
+					&configuration.Webhooks[i].FailurePolicy)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("webhook %s not found in %s", PodMutatingWebhookName, r.MutatingWebhookConfiguration)
+		}
+		if equality.Semantic.DeepEqual(original.Webhooks, configuration.Webhooks) {
+			return nil
+		}
+		return writer.Update(ctx, &configuration)
+	}); apierrors.IsNotFound(err) {
+		log.Info("MutatingWebhookConfiguration not found, registering the pod webhook once it is created",
+			"name", r.MutatingWebhookConfiguration)
+	} else if err != nil {
+		return fmt.Errorf("unable to update MutatingWebhookConfiguration %s: %w", r.MutatingWebhookConfiguration, err)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var configuration admissionregistrationv1.ValidatingWebhookConfiguration
+		if err := reader.Get(ctx, types.NamespacedName{Name: r.ValidatingWebhookConfiguration}, &configuration); err != nil {
+			return err
+		}
+		original := configuration.DeepCopy()
+		found := false
+		for i := range configuration.Webhooks {
+			if configuration.Webhooks[i].Name == PodValidatingWebhookName {
+				r.applyTo(&configuration.Webhooks[i].NamespaceSelector, &configuration.Webhooks[i].ObjectSelector,
+					&configuration.Webhooks[i].FailurePolicy)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("webhook %s not found in %s", PodValidatingWebhookName, r.ValidatingWebhookConfiguration)
+		}
+		if equality.Semantic.DeepEqual(original.Webhooks, configuration.Webhooks) {
+			return nil
+		}
+		return writer.Update(ctx, &configuration)
+	}); apierrors.IsNotFound(err) {
+		log.Info("ValidatingWebhookConfiguration not found, registering the pod webhook once it is created",
+			"name", r.ValidatingWebhookConfiguration)
+	} else if err != nil {
+		return fmt.Errorf("unable to update ValidatingWebhookConfiguration %s: %w", r.ValidatingWebhookConfiguration, err)
+	}
+	return nil
+}
+
+func (r *PodWebhookRegistration) applyTo(namespaceSelector, objectSelector **metav1.LabelSelector,
+	failurePolicy **admissionregistrationv1.FailurePolicyType) {
+	// Selectors that are not configured keep those of the deployed webhook
+	// configuration, like its exclusion of kube-system
+	if r.NamespaceSelector != nil {
+		*namespaceSelector = r.NamespaceSelector.DeepCopy()
+	}
+	if r.ObjectSelector != nil {
+		*objectSelector = r.ObjectSelector.DeepCopy()
+	}
+	policy := r.FailurePolicy
+	*failurePolicy = &policy
+}
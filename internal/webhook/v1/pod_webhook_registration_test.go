@@ -0,0 +1,185 @@
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Pod Webhook Registration", func() {
+	const configurationName = "pod-webhook-registration-test"
+
+	// The webhooks only match a resource nobody creates, so that they do not
+	// interfere with the other tests
+	rules := []admissionregistrationv1.RuleWithOperations{{
+		Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+		Rule: admissionregistrationv1.Rule{
+			APIGroups:   []string{"example.com"},
+			APIVersions: []string{"v1"},
+			Resources:   []string{"widgets"},
+		},
+	}}
+	url := "https://localhost:9443/unused"
+	clientConfig := admissionregistrationv1.WebhookClientConfig{URL: &url}
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+
+	// parse builds a registration of the test webhook configurations
+	parse := func(namespaceSelector, objectSelector, failurePolicy string) (*PodWebhookRegistration, error) {
+		registration, err := ParsePodWebhookRegistration(namespaceSelector, objectSelector, failurePolicy)
+		if registration != nil {
+			registration.MutatingWebhookConfiguration = configurationName
+			registration.ValidatingWebhookConfiguration = configurationName
+		}
+		return registration, err
+	}
+
+	BeforeEach(func() {
+		Expect(k8sClient.Create(ctx, &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: configurationName},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name:                    PodMutatingWebhookName,
+				ClientConfig:            clientConfig,
+				Rules:                   rules,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1"},
+			}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: configurationName},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+				Name:                    PodValidatingWebhookName,
+				ClientConfig:            clientConfig,
+				Rules:                   rules,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1"},
+			}},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: configurationName},
+		})).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: configurationName},
+		})).To(Succeed())
+	})
+
+	It("should apply the selectors and the failure policy to the pod webhooks", func() {
+		registration, err := parse("galactic.datumapis.com/enabled=true", "app in (web, db)", "Ignore")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registration.Apply(ctx, k8sClient, k8sClient)).To(Succeed())
+
+		namespaceSelector := &metav1.LabelSelector{
+			MatchLabels: map[string]string{"galactic.datumapis.com/enabled": "true"},
+		}
+		objectSelector := &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "app",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"db", "web"},
+			}},
+		}
+
+		var mutating admissionregistrationv1.MutatingWebhookConfiguration
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &mutating)).To(Succeed())
+		Expect(mutating.Webhooks[0].NamespaceSelector).To(Equal(namespaceSelector))
+		Expect(mutating.Webhooks[0].ObjectSelector).To(Equal(objectSelector))
+		Expect(mutating.Webhooks[0].FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Ignore)))
+
+		var validating admissionregistrationv1.ValidatingWebhookConfiguration
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &validating)).To(Succeed())
+		Expect(validating.Webhooks[0].NamespaceSelector).To(Equal(namespaceSelector))
+		Expect(validating.Webhooks[0].ObjectSelector).To(Equal(objectSelector))
+		Expect(validating.Webhooks[0].FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Ignore)))
+
+		By("leaving the selectors alone when none are configured")
+		registration, err = parse("", "", "Fail")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registration.Apply(ctx, k8sClient, k8sClient)).To(Succeed())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &mutating)).To(Succeed())
+		Expect(mutating.Webhooks[0].NamespaceSelector).To(Equal(namespaceSelector))
+		Expect(mutating.Webhooks[0].ObjectSelector).To(Equal(objectSelector))
+		Expect(mutating.Webhooks[0].FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Fail)))
+	})
+
+	It("should keep the namespace selector of the deployed configuration if none is configured", func() {
+		excludeKubeSystem := &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "kubernetes.io/metadata.name",
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{"kube-system"},
+			}},
+		}
+		var mutating admissionregistrationv1.MutatingWebhookConfiguration
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &mutating)).To(Succeed())
+		mutating.Webhooks[0].NamespaceSelector = excludeKubeSystem
+		Expect(k8sClient.Update(ctx, &mutating)).To(Succeed())
+
+		registration, err := parse("", "app=web", "Ignore")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registration.Apply(ctx, k8sClient, k8sClient)).To(Succeed())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &mutating)).To(Succeed())
+		Expect(mutating.Webhooks[0].NamespaceSelector).To(Equal(excludeKubeSystem))
+		Expect(mutating.Webhooks[0].ObjectSelector).To(Equal(&metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "web"},
+		}))
+		Expect(mutating.Webhooks[0].FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Ignore)))
+	})
+
+	It("should leave unchanged pod webhooks alone", func() {
+		registration, err := parse("", "", "Fail")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registration.Apply(ctx, k8sClient, k8sClient)).To(Succeed())
+
+		var mutating admissionregistrationv1.MutatingWebhookConfiguration
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &mutating)).To(Succeed())
+		Expect(registration.Apply(ctx, k8sClient, k8sClient)).To(Succeed())
+
+		var reapplied admissionregistrationv1.MutatingWebhookConfiguration
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &reapplied)).To(Succeed())
+		Expect(reapplied.ResourceVersion).To(Equal(mutating.ResourceVersion))
+	})
+
+	It("should tolerate a missing webhook configuration", func() {
+		registration, err := parse("", "", "Ignore")
+		Expect(err).NotTo(HaveOccurred())
+		registration.MutatingWebhookConfiguration = "missing-configuration"
+		Expect(registration.Apply(ctx, k8sClient, k8sClient)).To(Succeed())
+
+		By("registering the pod webhook of the existing configuration")
+		var validating admissionregistrationv1.ValidatingWebhookConfiguration
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: configurationName}, &validating)).To(Succeed())
+		Expect(validating.Webhooks[0].FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Ignore)))
+	})
+
+	It("should cache only the deployed webhook configurations", func() {
+		registration, err := ParsePodWebhookRegistration("", "", "Fail")
+		Expect(err).NotTo(HaveOccurred())
+		byObject := registration.CacheByObject()
+		Expect(byObject).To(HaveLen(2))
+		for object, options := range byObject {
+			switch object.(type) {
+			case *admissionregistrationv1.MutatingWebhookConfiguration:
+				Expect(options.Field.String()).To(Equal("metadata.name=" + PodMutatingWebhookConfigurationName))
+			case *admissionregistrationv1.ValidatingWebhookConfiguration:
+				Expect(options.Field.String()).To(Equal("metadata.name=" + PodValidatingWebhookConfigurationName))
+			}
+		}
+	})
+
+	DescribeTable("should reject invalid registrations",
+		func(namespaceSelector, objectSelector, failurePolicy string) {
+			_, err := ParsePodWebhookRegistration(namespaceSelector, objectSelector, failurePolicy)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown failure policy", "", "", "Retry"),
+		Entry("invalid namespace selector", "a in b", "", "Fail"),
+		Entry("invalid object selector", "", "!!", "Fail"),
+	)
+})
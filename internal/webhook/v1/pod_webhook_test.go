@@ -2,12 +2,18 @@ package v1
 
 import (
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
 )
@@ -93,7 +99,13 @@ var _ = Describe("Pod Webhook", func() {
 				Scheme: k8sClient.Scheme(),
 			}
 			Expect(defaulter.Default(ctx, pod)).Error().NotTo(HaveOccurred())
-			Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(Equal(fmt.Sprintf("%s@%s", VPCAttachmentName, VPCAttachmentInterface)))
+			Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(MatchJSON(
+				fmt.Sprintf(`[{"name":"%s","namespace":"default","interface":"%s","ips":["10.1.1.1/24","2001:10:1:1::1/64"]}]`,
+					VPCAttachmentName, VPCAttachmentInterface)))
+			Expect(pod.Labels).To(HaveKeyWithValue(galacticv1alpha.VPCAttachedPodLabel, "true"))
+			Expect(pod.Spec.ReadinessGates).To(ConsistOf(corev1.PodReadinessGate{
+				ConditionType: galacticv1alpha.PodConditionVPCReady,
+			}))
 		})
 	})
 
@@ -130,6 +142,82 @@ var _ = Describe("Pod Webhook", func() {
 			Expect(defaulter.Default(ctx, pod)).Error().To(HaveOccurred())
 		})
 	})
+
+	Context("When updating a Pod attached to a VPC attachment", func() {
+		var oldPod *corev1.Pod
+
+		BeforeEach(func() {
+			oldPod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						galacticv1alpha.VPCAttachedPodLabel: "true",
+					},
+					Annotations: map[string]string{
+						galacticv1alpha.VPCAttachmentAnnotation: VPCAttachmentName,
+						PodAnnotationMultusNetworks: fmt.Sprintf(`[{"name":"sriov-net","interface":"net1"},{"name":"%s","namespace":"default","interface":"%s"}]`,
+							VPCAttachmentName, VPCAttachmentInterface),
+					},
+				},
+			}
+			validator = PodCustomValidator{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		It("should allow changes to other annotations and networks", func() {
+			pod = oldPod.DeepCopy()
+			pod.Annotations["example.com/other"] = "value"
+			pod.Annotations[PodAnnotationMultusNetworks] = fmt.Sprintf(`[{"name":"%s","namespace":"default","interface":"%s"}]`,
+				VPCAttachmentName, VPCAttachmentInterface)
+			warnings, err := validator.ValidateUpdate(ctx, oldPod, pod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		DescribeTable("should reject changes to the networks of the VPC attachments",
+			func(mutate func(pod *corev1.Pod), path string) {
+				pod = oldPod.DeepCopy()
+				mutate(pod)
+				Expect(validator.ValidateUpdate(ctx, oldPod, pod)).Error().To(MatchError(ContainSubstring(path)))
+			},
+			Entry("changing the VPCAttachment annotation", func(pod *corev1.Pod) {
+				pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation] = "other-attachment"
+			}, galacticv1alpha.VPCAttachmentAnnotation),
+			Entry("removing the VPCAttachment annotation", func(pod *corev1.Pod) {
+				delete(pod.Annotations, galacticv1alpha.VPCAttachmentAnnotation)
+			}, galacticv1alpha.VPCAttachmentAnnotation),
+			Entry("adding the VPCAttachmentTemplate annotation", func(pod *corev1.Pod) {
+				pod.Annotations[galacticv1alpha.VPCAttachmentTemplateAnnotation] = "template"
+			}, galacticv1alpha.VPCAttachmentTemplateAnnotation),
+			Entry("changing the interface of the managed network", func(pod *corev1.Pod) {
+				pod.Annotations[PodAnnotationMultusNetworks] = fmt.Sprintf(`[{"name":"%s","namespace":"default","interface":"eth9"}]`,
+					VPCAttachmentName)
+			}, PodAnnotationMultusNetworks),
+			Entry("removing the managed network", func(pod *corev1.Pod) {
+				pod.Annotations[PodAnnotationMultusNetworks] = "sriov-net@net1"
+			}, PodAnnotationMultusNetworks),
+			Entry("removing the VPC-attached label", func(pod *corev1.Pod) {
+				delete(pod.Labels, galacticv1alpha.VPCAttachedPodLabel)
+			}, galacticv1alpha.VPCAttachedPodLabel),
+			Entry("changing the VPC-attached label", func(pod *corev1.Pod) {
+				pod.Labels[galacticv1alpha.VPCAttachedPodLabel] = "false"
+			}, galacticv1alpha.VPCAttachedPodLabel),
+		)
+
+		It("should warn when the VPC attachment is not ready", func() {
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: VPCAttachmentName, Namespace: "default"}, vpcAttachment)).To(Succeed())
+			vpcAttachment.Status.Ready = false
+			Expect(k8sClient.Status().Update(ctx, vpcAttachment)).To(Succeed())
+
+			warnings, err := validator.ValidateUpdate(ctx, oldPod, oldPod.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf(ContainSubstring("is not ready")))
+		})
+	})
 })
 
 var _ = Describe("Pod Webhook Without VPCAttachment Annotation", func() {
@@ -155,6 +243,7 @@ var _ = Describe("Pod Webhook Without VPCAttachment Annotation", func() {
 		}
 		Expect(defaulter.Default(ctx, pod)).NotTo(HaveOccurred())
 		Expect(pod.Annotations).NotTo(HaveKey(PodAnnotationMultusNetworks))
+		Expect(pod.Labels).NotTo(HaveKey(galacticv1alpha.VPCAttachedPodLabel))
 
 		validator := PodCustomValidator{
 			Client: k8sClient,
@@ -331,7 +420,8 @@ var _ = Describe("Pod Webhook Race Conditions", func() {
 				Scheme: k8sClient.Scheme(),
 			}
 			Expect(defaulter.Default(ctx, pod)).NotTo(HaveOccurred())
-			Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(Equal(fmt.Sprintf("%s@%s", "ready-attachment", "galactic0")))
+			Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(MatchJSON(
+				`[{"name":"ready-attachment","namespace":"default","interface":"galactic0","ips":["10.1.1.1/24"]}]`))
 
 			validator = PodCustomValidator{
 				Client: k8sClient,
@@ -341,3 +431,458 @@ var _ = Describe("Pod Webhook Race Conditions", func() {
 		})
 	})
 })
+
+var _ = Describe("Pod Webhook With Multiple VPCAttachments", func() {
+	newVPCAttachment := func(name, interfaceName, address string) *galacticv1alpha.VPCAttachment {
+		return &galacticv1alpha.VPCAttachment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCAttachmentSpec{
+				VPC: corev1.ObjectReference{
+					APIVersion: "galactic.datumapis.com/v1alpha",
+					Kind:       "VPC",
+					Name:       "vpc-sample",
+					Namespace:  "default",
+				},
+				Interface: galacticv1alpha.VPCAttachmentInterface{
+					Name:      interfaceName,
+					Addresses: []string{address},
+				},
+			},
+		}
+	}
+
+	newPod := func(annotation string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-pod",
+				Namespace: "default",
+				Annotations: map[string]string{
+					galacticv1alpha.VPCAttachmentAnnotation: annotation,
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "test-container",
+						Image: "test:latest",
+					},
+				},
+			},
+		}
+	}
+
+	// Network selection elements rendered for the VPCAttachments
+	const managedA = `{"name":"multi-attachment-a","namespace":"default","interface":"galactic0","ips":["10.1.1.11/24"]}`
+	const managedB = `{"name":"multi-attachment-b","namespace":"default","interface":"galactic1","ips":["10.1.1.12/24"]}`
+
+	var vpcAttachments []*galacticv1alpha.VPCAttachment
+
+	BeforeEach(func() {
+		vpcAttachments = []*galacticv1alpha.VPCAttachment{
+			newVPCAttachment("multi-attachment-a", "galactic0", "10.1.1.11/24"),
+			newVPCAttachment("multi-attachment-b", "galactic1", "10.1.1.12/24"),
+			newVPCAttachment("multi-attachment-c", "galactic0", "10.1.1.13/24"),
+		}
+		for _, vpcAttachment := range vpcAttachments {
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+			vpcAttachment.Status.Ready = true
+			Expect(k8sClient.Status().Update(ctx, vpcAttachment)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		for _, vpcAttachment := range vpcAttachments {
+			Expect(k8sClient.Delete(ctx, vpcAttachment)).To(Succeed())
+		}
+	})
+
+	DescribeTable("should render one network selection element per VPCAttachment",
+		func(annotation string) {
+			pod := newPod(annotation)
+
+			defaulter := PodCustomDefaulter{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(defaulter.Default(ctx, pod)).To(Succeed())
+			Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(MatchJSON("[" + managedA + "," + managedB + "]"))
+
+			validator := PodCustomValidator{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(validator.ValidateCreate(ctx, pod)).Error().NotTo(HaveOccurred())
+		},
+		Entry("comma-separated list", "multi-attachment-a, multi-attachment-b"),
+		Entry("JSON list", `["multi-attachment-a","multi-attachment-b"]`),
+	)
+
+	DescribeTable("should merge with the networks already requested for the pod",
+		func(existing, expected string) {
+			pod := newPod("multi-attachment-a,multi-attachment-b")
+			pod.Annotations[PodAnnotationMultusNetworks] = existing
+
+			defaulter := PodCustomDefaulter{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(defaulter.Default(ctx, pod)).To(Succeed())
+			Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(MatchJSON(expected))
+		},
+		Entry("no networks", "",
+			"["+managedA+","+managedB+"]"),
+		Entry("other networks in the short form", "sriov-net@net1, kube-system/macvlan-net",
+			`[{"name":"sriov-net","interface":"net1"},{"name":"macvlan-net","namespace":"kube-system"},`+managedA+","+managedB+"]"),
+		Entry("other networks in the JSON form", `[{"name":"sriov-net","interface":"net1","mac":"c2:b0:57:49:47:f1"}]`,
+			`[{"name":"sriov-net","interface":"net1","mac":"c2:b0:57:49:47:f1"},`+managedA+","+managedB+"]"),
+		Entry("managed networks in the short form", "multi-attachment-b@eth9,sriov-net@net1",
+			"["+managedB+`,{"name":"sriov-net","interface":"net1"},`+managedA+"]"),
+		Entry("managed networks in the JSON form",
+			`[{"name":"multi-attachment-a","namespace":"default","interface":"eth9"},{"name":"sriov-net","interface":"net1"}]`,
+			"["+managedA+`,{"name":"sriov-net","interface":"net1"},`+managedB+"]"),
+		Entry("a network of the same name in another namespace", "other/multi-attachment-a@net1",
+			`[{"name":"multi-attachment-a","namespace":"other","interface":"net1"},`+managedA+","+managedB+"]"),
+	)
+
+	DescribeTable("should reject networks that cannot be merged",
+		func(existing string) {
+			pod := newPod("multi-attachment-a,multi-attachment-b")
+			pod.Annotations[PodAnnotationMultusNetworks] = existing
+
+			defaulter := PodCustomDefaulter{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(defaulter.Default(ctx, pod)).NotTo(Succeed())
+		},
+		Entry("an invalid JSON form", `[{"name":"sriov-net"`),
+		Entry("an empty entry in the short form", "sriov-net,,macvlan-net"),
+		Entry("another network using a managed interface", "sriov-net@galactic0"),
+	)
+
+	It("should reject VPCAttachments using the same interface name", func() {
+		pod := newPod("multi-attachment-a,multi-attachment-c")
+
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, pod)).To(MatchError(ContainSubstring("galactic0")))
+
+		validator := PodCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(validator.ValidateCreate(ctx, pod)).Error().To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Pod Webhook With Exclusive VPCAttachment", func() {
+	var (
+		vpcAttachment *galacticv1alpha.VPCAttachment
+		boundPod      *corev1.Pod
+	)
+
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Annotations: map[string]string{
+					galacticv1alpha.VPCAttachmentAnnotation: "exclusive-attachment",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "test-container",
+						Image: "test:latest",
+					},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		vpcAttachment = &galacticv1alpha.VPCAttachment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "exclusive-attachment",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCAttachmentSpec{
+				VPC: corev1.ObjectReference{
+					APIVersion: "galactic.datumapis.com/v1alpha",
+					Kind:       "VPC",
+					Name:       "vpc-sample",
+					Namespace:  "default",
+				},
+				Interface: galacticv1alpha.VPCAttachmentInterface{
+					Name:      "galactic0",
+					Addresses: []string{"10.1.1.21/24"},
+				},
+				BindingMode: galacticv1alpha.VPCAttachmentBindingModeExclusive,
+			},
+		}
+		Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+		vpcAttachment.Status.Ready = true
+		Expect(k8sClient.Status().Update(ctx, vpcAttachment)).To(Succeed())
+
+		boundPod = newPod("exclusive-pod")
+		Expect(k8sClient.Create(ctx, boundPod)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, boundPod, client.GracePeriodSeconds(0)))).To(Succeed())
+		Expect(k8sClient.Delete(ctx, vpcAttachment)).To(Succeed())
+	})
+
+	It("should reject a second pod while the bound pod is active", func() {
+		validator := PodCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(validator.ValidateCreate(ctx, newPod("exclusive-pod-2"))).Error().To(MatchError(ContainSubstring("exclusive-pod")))
+
+		By("letting the bound pod terminate")
+		boundPod.Status.Phase = corev1.PodSucceeded
+		Expect(k8sClient.Status().Update(ctx, boundPod)).To(Succeed())
+		Expect(validator.ValidateCreate(ctx, newPod("exclusive-pod-2"))).Error().NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Pod Webhook With VPCAttachmentTemplate", func() {
+	var template *galacticv1alpha.VPCAttachmentTemplate
+
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Annotations: map[string]string{
+					galacticv1alpha.VPCAttachmentTemplateAnnotation: "web-template",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "test-container",
+						Image: "test:latest",
+					},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		template = &galacticv1alpha.VPCAttachmentTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-template",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCAttachmentTemplateSpec{
+				VPC: corev1.ObjectReference{
+					APIVersion: "galactic.datumapis.com/v1alpha",
+					Kind:       "VPC",
+					Name:       "vpc-sample",
+				},
+				Interface: galacticv1alpha.VPCAttachmentTemplateInterface{
+					Name:          "galactic0",
+					AddressRanges: []string{"10.1.1.192/26"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, template)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &galacticv1alpha.VPCAttachment{}, client.InNamespace("default"),
+			client.MatchingLabels{galacticv1alpha.VPCAttachmentTemplateLabel: "web-template"})).To(Succeed())
+		Expect(k8sClient.Delete(ctx, template)).To(Succeed())
+	})
+
+	newStatefulSetPod := func(name, statefulSet string) *corev1.Pod {
+		pod := newPod(name)
+		isController := true
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+			Name:       statefulSet,
+			UID:        types.UID("f7d5c4a2-1e6b-4c1f-9b8a-0d3e5f6a7b8c-" + statefulSet),
+			Controller: &isController,
+		}}
+		return pod
+	}
+
+	It("should stamp out a VPCAttachment for the pod", func() {
+		pod := newPod("template-pod")
+
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, pod)).To(Succeed())
+		Expect(pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation]).To(Equal("template-pod-web-template"))
+		Expect(pod.Annotations[PodAnnotationMultusNetworks]).To(MatchJSON(
+			`[{"name":"template-pod-web-template","namespace":"default","interface":"galactic0"}]`))
+		Expect(pod.Labels).To(HaveKeyWithValue(galacticv1alpha.VPCAttachedPodLabel, "true"))
+
+		vpcAttachment := &galacticv1alpha.VPCAttachment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "template-pod-web-template"}, vpcAttachment)).To(Succeed())
+		Expect(vpcAttachment.Labels).To(HaveKeyWithValue(galacticv1alpha.VPCAttachmentPodLabel, "template-pod"))
+		Expect(vpcAttachment.Spec.VPC.Namespace).To(Equal("default"))
+		Expect(vpcAttachment.Spec.BindingMode).To(Equal(galacticv1alpha.VPCAttachmentBindingModeExclusive))
+
+		By("leaving the allocation of the addresses to the controller")
+		Expect(vpcAttachment.Spec.Interface.Addresses).To(BeEmpty())
+		Expect(vpcAttachment.Spec.Interface.AddressRanges).To(Equal([]string{"10.1.1.192/26"}))
+		Expect(vpcAttachment.Spec.Interface.AddressIndex).To(BeNil())
+
+		By("admitting the pod although its VPCAttachment is not ready yet")
+		validator := PodCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(validator.ValidateCreate(ctx, pod)).Error().NotTo(HaveOccurred())
+
+		By("reusing the VPCAttachment for a pod of the same name")
+		pod = newPod("template-pod")
+		Expect(defaulter.Default(ctx, pod)).To(Succeed())
+		Expect(pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation]).To(Equal("template-pod-web-template"))
+	})
+
+	It("should reject a pod with a generated name", func() {
+		pod := newPod("")
+		pod.GenerateName = "generated-pod-"
+
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, pod)).To(MatchError(ContainSubstring("needs a name")))
+		Expect(pod.Name).To(BeEmpty())
+
+		vpcAttachments := &galacticv1alpha.VPCAttachmentList{}
+		Expect(k8sClient.List(ctx, vpcAttachments, client.InNamespace("default"),
+			client.MatchingLabels{galacticv1alpha.VPCAttachmentTemplateLabel: "web-template"})).To(Succeed())
+		Expect(vpcAttachments.Items).To(BeEmpty())
+	})
+
+	It("should reject a pod whose VPCAttachment name is too long", func() {
+		pod := newPod(strings.Repeat("a", 250))
+
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, pod)).To(MatchError(ContainSubstring("invalid name")))
+
+		validator := PodCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation] = pod.Name + "-web-template"
+		Expect(validator.ValidateCreate(ctx, pod)).Error().To(MatchError(ContainSubstring("invalid name")))
+
+		vpcAttachments := &galacticv1alpha.VPCAttachmentList{}
+		Expect(k8sClient.List(ctx, vpcAttachments, client.InNamespace("default"),
+			client.MatchingLabels{galacticv1alpha.VPCAttachmentTemplateLabel: "web-template"})).To(Succeed())
+		Expect(vpcAttachments.Items).To(BeEmpty())
+	})
+
+	It("should not stamp out a VPCAttachment on a dry run", func() {
+		pod := newPod("dry-run-pod")
+
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		dryRun := true
+		dryRunCtx := admission.NewContextWithRequest(ctx, admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{DryRun: &dryRun},
+		})
+		Expect(defaulter.Default(dryRunCtx, pod)).To(Succeed())
+		Expect(pod.Annotations[galacticv1alpha.VPCAttachmentAnnotation]).To(Equal("dry-run-pod-web-template"))
+
+		vpcAttachment := &galacticv1alpha.VPCAttachment{}
+		err := k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "dry-run-pod-web-template"}, vpcAttachment)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		By("admitting the pod on a dry run")
+		validator := PodCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(validator.ValidateCreate(dryRunCtx, pod)).Error().NotTo(HaveOccurred())
+	})
+
+	It("should stamp out VPCAttachments for concurrent pods", func() {
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+
+		const pods = 5
+		errs := make(chan error, pods)
+		for i := range pods {
+			go func() {
+				errs <- defaulter.Default(ctx, newPod(fmt.Sprintf("concurrent-pod-%d", i)))
+			}()
+		}
+		for range pods {
+			Expect(<-errs).To(Succeed())
+		}
+
+		vpcAttachments := &galacticv1alpha.VPCAttachmentList{}
+		Expect(k8sClient.List(ctx, vpcAttachments, client.InNamespace("default"),
+			client.MatchingLabels{galacticv1alpha.VPCAttachmentTemplateLabel: "web-template"})).To(Succeed())
+		Expect(vpcAttachments.Items).To(HaveLen(pods))
+		for _, vpcAttachment := range vpcAttachments.Items {
+			// The controller reserves the addresses one at a time
+			Expect(vpcAttachment.Spec.Interface.Addresses).To(BeEmpty())
+		}
+	})
+
+	It("should ask for the address matching the ordinal of a StatefulSet pod", func() {
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, newStatefulSetPod("web-3", "web"))).To(Succeed())
+
+		vpcAttachment := &galacticv1alpha.VPCAttachment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web-3-web-template"}, vpcAttachment)).To(Succeed())
+		Expect(vpcAttachment.Spec.Interface.Addresses).To(BeEmpty())
+		Expect(vpcAttachment.Spec.Interface.AddressIndex).To(HaveValue(Equal(int32(3))))
+	})
+
+	It("should not ask for an address beyond the address ranges", func() {
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, newStatefulSetPod("web-70", "web"))).To(Succeed())
+
+		vpcAttachment := &galacticv1alpha.VPCAttachment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web-70-web-template"}, vpcAttachment)).To(Succeed())
+		Expect(vpcAttachment.Spec.Interface.AddressIndex).To(BeNil())
+	})
+
+	It("should leave it to the controller to separate two StatefulSets sharing a template", func() {
+		defaulter := PodCustomDefaulter{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		Expect(defaulter.Default(ctx, newStatefulSetPod("web-0", "web"))).To(Succeed())
+		Expect(defaulter.Default(ctx, newStatefulSetPod("api-0", "api"))).To(Succeed())
+
+		for _, name := range []string{"web-0-web-template", "api-0-web-template"} {
+			vpcAttachment := &galacticv1alpha.VPCAttachment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, vpcAttachment)).To(Succeed())
+			Expect(vpcAttachment.Spec.Interface.Addresses).To(BeEmpty())
+			Expect(vpcAttachment.Spec.Interface.AddressIndex).To(HaveValue(Equal(int32(0))))
+		}
+	})
+})
@@ -0,0 +1,171 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/ipam"
+	"github.com/datum-cloud/galactic-operator/internal/podnetworks"
+)
+
+// +kubebuilder:rbac:groups=galactic.datumapis.com,resources=vpcattachmenttemplates,verbs=get;list;watch
+
+// StatefulSetPodIndexLabel carries the ordinal of a StatefulSet Pod.
+const StatefulSetPodIndexLabel = "apps.kubernetes.io/pod-index"
+
+// stampVPCAttachments returns a VPCAttachment for the Pod from each of the
+// VPCAttachmentTemplates listed in its annotation. VPCAttachments stamped out
+// for an earlier Pod of the same name are reused. Missing ones are only
+// created if create is set, so that dry runs and validation have no side
+// effects. VPCAttachments whose Pod never gets created are deleted by the
+// controller after a grace period.
+func stampVPCAttachments(k8sClient client.Client, ctx context.Context, pod *corev1.Pod, create bool) ([]galacticv1alpha.VPCAttachment, error) {
+	templateNames, err := podnetworks.ParseVPCAttachmentNames(pod.Annotations[galacticv1alpha.VPCAttachmentTemplateAnnotation])
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing is created unless every VPCAttachment can be
+	for _, templateName := range templateNames {
+		if errs := validation.IsDNS1123Subdomain(stampedName(pod, templateName)); len(errs) > 0 {
+			return nil, fmt.Errorf("VPCAttachment %s stamped out from VPCAttachmentTemplate %s for Pod %s has an invalid name: %s",
+				stampedName(pod, templateName), templateName, pod.Name, strings.Join(errs, ", "))
+		}
+	}
+
+	vpcAttachments := make([]galacticv1alpha.VPCAttachment, 0, len(templateNames))
+	for _, templateName := range templateNames {
+		var template galacticv1alpha.VPCAttachmentTemplate
+		if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: templateName}, &template); err != nil {
+			return nil, err
+		}
+
+		name := stampedName(pod, templateName)
+		var existing galacticv1alpha.VPCAttachment
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, &existing)
+		if err == nil {
+			if existing.Labels[galacticv1alpha.VPCAttachmentPodLabel] != pod.Name ||
+				existing.Labels[galacticv1alpha.VPCAttachmentTemplateLabel] != templateName {
+				return nil, fmt.Errorf("VPCAttachment %s/%s already exists and was not stamped out from VPCAttachmentTemplate %s",
+					pod.Namespace, name, templateName)
+			}
+			if !existing.DeletionTimestamp.IsZero() {
+				return nil, fmt.Errorf("VPCAttachment %s/%s of a previous Pod %s is still being deleted", pod.Namespace, name, pod.Name)
+			}
+			vpcAttachments = append(vpcAttachments, existing)
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		vpcAttachment := stampVPCAttachment(pod, &template, name)
+		if err := dropUnavailableAddressIndex(k8sClient, ctx, vpcAttachment); err != nil {
+			return nil, err
+		}
+		if create {
+			if err := k8sClient.Create(ctx, vpcAttachment); err != nil {
+				return nil, err
+			}
+		}
+		vpcAttachments = append(vpcAttachments, *vpcAttachment)
+	}
+	return vpcAttachments, nil
+}
+
+// stampedName returns the name of the VPCAttachment stamped out from the
+// VPCAttachmentTemplate for the Pod.
+func stampedName(pod *corev1.Pod, templateName string) string {
+	return fmt.Sprintf("%s-%s", pod.Name, templateName)
+}
+
+// stampVPCAttachment returns the VPCAttachment named name stamped out from
+// template for the Pod. Its addresses are allocated by the controller, which
+// reserves them atomically. Pods of a StatefulSet ask for the addresses
+// matching their ordinal.
+func stampVPCAttachment(pod *corev1.Pod, template *galacticv1alpha.VPCAttachmentTemplate, name string) *galacticv1alpha.VPCAttachment {
+	vpc := template.Spec.VPC
+	if vpc.Namespace == "" {
+		vpc.Namespace = template.Namespace
+	}
+	var addressIndex *int32
+	if ordinal, ok := statefulSetOrdinal(pod); ok {
+		addressIndex = &ordinal
+	}
+	return &galacticv1alpha.VPCAttachment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pod.Namespace,
+			Labels: map[string]string{
+				galacticv1alpha.VPCAttachmentPodLabel:      pod.Name,
+				galacticv1alpha.VPCAttachmentTemplateLabel: template.Name,
+			},
+		},
+		Spec: galacticv1alpha.VPCAttachmentSpec{
+			VPC: vpc,
+			Interface: galacticv1alpha.VPCAttachmentInterface{
+				Name:          template.Spec.Interface.Name,
+				AddressRanges: template.Spec.Interface.AddressRanges,
+				AddressIndex:  addressIndex,
+				DefaultRoute:  template.Spec.Interface.DefaultRoute,
+			},
+			Routes:         template.Spec.Routes,
+			Rules:          template.Spec.Rules,
+			SecurityGroups: template.Spec.SecurityGroups,
+			BindingMode:    galacticv1alpha.VPCAttachmentBindingModeExclusive,
+		},
+	}
+}
+
+// dropUnavailableAddressIndex clears the address index of a stamped
+// VPCAttachment if its address ranges have no address at that index, so that
+// the Pod gets the lowest free addresses instead of being rejected.
+func dropUnavailableAddressIndex(k8sClient client.Client, ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) error {
+	index := vpcAttachment.Spec.Interface.AddressIndex
+	if index == nil {
+		return nil
+	}
+	ranges := vpcAttachment.Spec.Interface.AddressRanges
+	if len(ranges) == 0 {
+		var vpc galacticv1alpha.VPC
+		vpcNamespacedName := types.NamespacedName{Namespace: vpcAttachment.Spec.VPC.Namespace, Name: vpcAttachment.Spec.VPC.Name}
+		if err := k8sClient.Get(ctx, vpcNamespacedName, &vpc); err != nil {
+			// The VPCAttachment webhook rejects a missing VPC
+			return client.IgnoreNotFound(err)
+		}
+		ranges = vpc.Spec.Networks
+	}
+	if *index > galacticv1alpha.VPCAttachmentMaxAddressIndex || !ipam.HasNth(ranges, int(*index)) {
+		vpcAttachment.Spec.Interface.AddressIndex = nil
+	}
+	return nil
+}
+
+// statefulSetOrdinal returns the ordinal of a Pod controlled by a StatefulSet.
+func statefulSetOrdinal(pod *corev1.Pod) (int32, bool) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" {
+		return 0, false
+	}
+	index, exists := pod.Labels[StatefulSetPodIndexLabel]
+	if !exists {
+		// Older clusters only encode the ordinal in the name
+		index = pod.Name[strings.LastIndex(pod.Name, "-")+1:]
+	}
+	ordinal, err := strconv.ParseInt(index, 10, 32)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return int32(ordinal), true
+}
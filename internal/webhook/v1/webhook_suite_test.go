@@ -30,6 +30,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -39,6 +40,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+	webhookv1alpha "github.com/datum-cloud/galactic-operator/internal/webhook/v1alpha"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -67,6 +71,8 @@ var _ = BeforeSuite(func() {
 	var err error
 	err = corev1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = galacticv1alpha.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
@@ -111,6 +117,12 @@ var _ = BeforeSuite(func() {
 	err = SetupPodWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	// VPCAttachments created by the tests are admitted by the galactic webhooks
+	err = webhookv1alpha.SetupVPCWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+	err = webhookv1alpha.SetupVPCAttachmentWebhookWithManager(mgr, false)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:webhook
 
 	go func() {
@@ -130,6 +142,21 @@ var _ = BeforeSuite(func() {
 
 		return conn.Close()
 	}).Should(Succeed())
+
+	By("creating the VPC referenced by the VPCAttachments in the tests")
+	vpc := &galacticv1alpha.VPC{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vpc-sample",
+			Namespace: "default",
+		},
+		Spec: galacticv1alpha.VPCSpec{
+			Networks: []string{
+				"10.1.1.0/24",
+				"2001:10:1:1::/64",
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
 })
 
 var _ = AfterSuite(func() {
@@ -0,0 +1,131 @@
+package v1alpha
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/transitgateway"
+)
+
+// nolint:unused
+var transitgatewaylog = logf.Log.WithName("transitgateway-resource")
+
+func SetupTransitGatewayWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&galacticv1alpha.TransitGateway{}).
+		WithValidator(&TransitGatewayCustomValidator{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-galactic-datumapis-com-v1alpha-transitgateway,mutating=false,failurePolicy=fail,sideEffects=None,groups=galactic.datumapis.com,resources=transitgateways,verbs=create;update,versions=v1alpha,name=vtransitgateway-v1alpha.kb.io,admissionReviewVersions=v1
+
+type TransitGatewayCustomValidator struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+var _ webhook.CustomValidator = &TransitGatewayCustomValidator{}
+
+func (v *TransitGatewayCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	transitGateway, ok := obj.(*galacticv1alpha.TransitGateway)
+	if !ok {
+		return nil, fmt.Errorf("expected a TransitGateway object but got %T", obj)
+	}
+
+	allErrs := validateRouteTables(transitGateway)
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("TransitGateway").GroupKind(), transitGateway.Name, allErrs)
+	}
+
+	return nil, nil
+}
+
+func (v *TransitGatewayCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	_, ok := oldObj.(*galacticv1alpha.TransitGateway)
+	if !ok {
+		return nil, fmt.Errorf("expected a TransitGateway object for the oldObj but got %T", oldObj)
+	}
+	transitGateway, ok := newObj.(*galacticv1alpha.TransitGateway)
+	if !ok {
+		return nil, fmt.Errorf("expected a TransitGateway object for the newObj but got %T", newObj)
+	}
+
+	allErrs := validateRouteTables(transitGateway)
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("TransitGateway").GroupKind(), transitGateway.Name, allErrs)
+	}
+
+	warnings, err := v.removedRouteTableWarnings(ctx, transitGateway)
+	if err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+func (v *TransitGatewayCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	_, ok := obj.(*galacticv1alpha.TransitGateway)
+	if !ok {
+		return nil, fmt.Errorf("expected a TransitGateway object but got %T", obj)
+	}
+
+	return nil, nil
+}
+
+// validateRouteTables checks that route tables only propagate from route
+// tables of the TransitGateway and that their namespace selectors are valid.
+func validateRouteTables(transitGateway *galacticv1alpha.TransitGateway) field.ErrorList {
+	var allErrs field.ErrorList
+	routeTablesPath := field.NewPath("spec", "routeTables")
+	for i, routeTable := range transitGateway.Spec.RouteTables {
+		for j, name := range routeTable.PropagateFrom {
+			if transitgateway.RouteTable(transitGateway, name) == nil {
+				allErrs = append(allErrs, field.NotFound(routeTablesPath.Index(i).Child("propagateFrom").Index(j), name))
+			}
+		}
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(routeTable.NamespaceSelector,
+			metav1validation.LabelSelectorValidationOptions{}, routeTablesPath.Index(i).Child("namespaceSelector"))...)
+	}
+	return allErrs
+}
+
+// removedRouteTableWarnings warns about VPCs associated with route tables the
+// TransitGateway no longer has or that no longer select their namespace,
+// which disconnects them.
+func (v *TransitGatewayCustomValidator) removedRouteTableWarnings(ctx context.Context, transitGateway *galacticv1alpha.TransitGateway) (admission.Warnings, error) {
+	var vpcs galacticv1alpha.VPCList
+	if err := v.List(ctx, &vpcs); err != nil {
+		return nil, err
+	}
+	var warnings admission.Warnings
+	for _, vpc := range transitgateway.Associated(transitGateway.Name, vpcs.Items) {
+		routeTable := transitgateway.RouteTable(transitGateway, vpc.Spec.TransitGateway.RouteTable)
+		if routeTable == nil {
+			warnings = append(warnings, fmt.Sprintf("VPC %s/%s is associated with route table %s, which does not exist",
+				vpc.Namespace, vpc.Name, vpc.Spec.TransitGateway.RouteTable))
+			continue
+		}
+		permitted, err := transitgateway.Permitted(ctx, v.Client, routeTable, vpc.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !permitted {
+			warnings = append(warnings, fmt.Sprintf("VPC %s/%s is associated with route table %s, which does not select its namespace",
+				vpc.Namespace, vpc.Name, vpc.Spec.TransitGateway.RouteTable))
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,100 @@
+package v1alpha
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("TransitGateway Webhook", func() {
+	var validator TransitGatewayCustomValidator
+
+	BeforeEach(func() {
+		validator = TransitGatewayCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+	})
+
+	transitGatewayWithRouteTables := func(routeTables ...galacticv1alpha.TransitGatewayRouteTable) *galacticv1alpha.TransitGateway {
+		return &galacticv1alpha.TransitGateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-hub"},
+			Spec:       galacticv1alpha.TransitGatewaySpec{RouteTables: routeTables},
+		}
+	}
+
+	Context("When creating a TransitGateway", func() {
+		It("should admit route tables propagating from each other", func() {
+			transitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", PropagateFrom: []string{"shared"}},
+				galacticv1alpha.TransitGatewayRouteTable{Name: "shared", PropagateFrom: []string{"spokes", "shared"}},
+			)
+			Expect(validator.ValidateCreate(ctx, transitGateway)).Error().NotTo(HaveOccurred())
+		})
+
+		It("should reject an invalid namespace selector", func() {
+			transitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}},
+				}},
+			)
+			Expect(validator.ValidateCreate(ctx, transitGateway)).Error().To(
+				MatchError(ContainSubstring("spec.routeTables[0].namespaceSelector")))
+		})
+
+		It("should reject propagating from a missing route table", func() {
+			transitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", PropagateFrom: []string{"shared"}},
+			)
+			Expect(validator.ValidateCreate(ctx, transitGateway)).Error().To(HaveOccurred())
+		})
+	})
+
+	Context("When updating a TransitGateway with associated VPCs", func() {
+		var vpc *galacticv1alpha.VPC
+
+		BeforeEach(func() {
+			vpc = &galacticv1alpha.VPC{
+				ObjectMeta: metav1.ObjectMeta{Name: "hub-vpc", Namespace: "default"},
+				Spec: galacticv1alpha.VPCSpec{
+					Networks:       []string{"10.31.0.0/16"},
+					TransitGateway: &galacticv1alpha.VPCTransitGatewayAssociation{Name: "test-hub", RouteTable: "spokes"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, vpc)).To(Succeed())
+		})
+
+		It("should warn about removing a route table in use", func() {
+			oldTransitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", NamespaceSelector: &metav1.LabelSelector{}})
+			warnings, err := validator.ValidateUpdate(ctx, oldTransitGateway, oldTransitGateway.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+
+			transitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "shared", NamespaceSelector: &metav1.LabelSelector{}})
+			warnings, err = validator.ValidateUpdate(ctx, oldTransitGateway, transitGateway)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+
+		It("should warn about no longer selecting the namespace of an associated VPC", func() {
+			oldTransitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", NamespaceSelector: &metav1.LabelSelector{}})
+			transitGateway := transitGatewayWithRouteTables(
+				galacticv1alpha.TransitGatewayRouteTable{Name: "spokes", NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"kubernetes.io/metadata.name": "other"},
+				}})
+			warnings, err := validator.ValidateUpdate(ctx, oldTransitGateway, transitGateway)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf(ContainSubstring("does not select its namespace")))
+		})
+	})
+})
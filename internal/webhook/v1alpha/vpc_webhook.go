@@ -0,0 +1,271 @@
+package v1alpha
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+	"github.com/datum-cloud/galactic-operator/internal/fieldindex"
+	"github.com/datum-cloud/galactic-operator/internal/identifier"
+	"github.com/datum-cloud/galactic-operator/internal/transitgateway"
+)
+
+// nolint:unused
+var vpclog = logf.Log.WithName("vpc-resource")
+
+func SetupVPCWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&galacticv1alpha.VPC{}).
+		WithValidator(&VPCCustomValidator{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-galactic-datumapis-com-v1alpha-vpc,mutating=false,failurePolicy=fail,sideEffects=None,groups=galactic.datumapis.com,resources=vpcs,verbs=create;update,versions=v1alpha,name=vvpc-v1alpha.kb.io,admissionReviewVersions=v1
+
+type VPCCustomValidator struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+var _ webhook.CustomValidator = &VPCCustomValidator{}
+
+func (v *VPCCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	vpc, ok := obj.(*galacticv1alpha.VPC)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPC object but got %T", obj)
+	}
+
+	allErrs := validateVPCNetworks(vpc)
+	allErrs = append(allErrs, validateRequestedIdentifier(field.NewPath("spec", "identifier"), vpc.Spec.Identifier, identifier.MaxVPC)...)
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPC").GroupKind(), vpc.Name, allErrs)
+	}
+
+	warnings, allErrs, err := v.validateTransitGateway(ctx, vpc, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(allErrs) > 0 {
+		return warnings, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPC").GroupKind(), vpc.Name, allErrs)
+	}
+	return warnings, nil
+}
+
+func (v *VPCCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldVPC, ok := oldObj.(*galacticv1alpha.VPC)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPC object for the oldObj but got %T", oldObj)
+	}
+	vpc, ok := newObj.(*galacticv1alpha.VPC)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPC object for the newObj but got %T", newObj)
+	}
+
+	allErrs := validateVPCNetworks(vpc)
+	identifierPath := field.NewPath("spec", "identifier")
+	allErrs = append(allErrs, validateRequestedIdentifier(identifierPath, vpc.Spec.Identifier, identifier.MaxVPC)...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(vpc.Spec.Identifier, oldVPC.Spec.Identifier, identifierPath)...)
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPC").GroupKind(), vpc.Name, allErrs)
+	}
+
+	allErrs, err := v.validateRemovedNetworks(ctx, oldVPC, vpc)
+	if err != nil {
+		return nil, err
+	}
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPC").GroupKind(), vpc.Name, allErrs)
+	}
+
+	// An association that lost its permission must not keep the VPC from
+	// being updated, e.g. to remove its finalizer
+	associationChanged := !equality.Semantic.DeepEqual(oldVPC.Spec.TransitGateway, vpc.Spec.TransitGateway)
+	warnings, allErrs, err := v.validateTransitGateway(ctx, vpc, associationChanged)
+	if err != nil {
+		return nil, err
+	}
+	if len(allErrs) > 0 {
+		return warnings, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPC").GroupKind(), vpc.Name, allErrs)
+	}
+	return warnings, nil
+}
+
+func (v *VPCCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	_, ok := obj.(*galacticv1alpha.VPC)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPC object but got %T", obj)
+	}
+
+	return nil, nil
+}
+
+// validateRequestedIdentifier checks that a requested identifier, if any, is a
+// valid hexadecimal value for the given maximum.
+func validateRequestedIdentifier(path *field.Path, value string, max uint64) field.ErrorList {
+	if value == "" {
+		return nil
+	}
+	if _, err := new(identifier.Identifier).FromString(value, max); err != nil {
+		return field.ErrorList{field.Invalid(path, value, err.Error())}
+	}
+	return nil
+}
+
+// validateVPCNetworks checks that every network parses and that no two
+// networks are duplicates of or overlap with each other.
+func validateVPCNetworks(vpc *galacticv1alpha.VPC) field.ErrorList {
+	var allErrs field.ErrorList
+	networksPath := field.NewPath("spec", "networks")
+
+	networks := make([]*net.IPNet, len(vpc.Spec.Networks))
+	for i, network := range vpc.Spec.Networks {
+		ipNet, err := cniconfig.ParseNetwork(network)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(networksPath.Index(i), network, err.Error()))
+			continue
+		}
+		networks[i] = ipNet
+	}
+
+	for i := range networks {
+		if networks[i] == nil {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if networks[j] == nil {
+				continue
+			}
+			if networks[i].String() == networks[j].String() {
+				allErrs = append(allErrs, field.Duplicate(networksPath.Index(i), vpc.Spec.Networks[i]))
+				break
+			}
+			if cniconfig.NetworksOverlap(networks[i], networks[j]) {
+				allErrs = append(allErrs, field.Invalid(networksPath.Index(i), vpc.Spec.Networks[i],
+					fmt.Sprintf("overlaps with network %q", vpc.Spec.Networks[j])))
+				break
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateTransitGateway rejects an association with a route table that does
+// not select the namespace of the VPC, or only warns about it unless enforce
+// is set. It warns about an association with a TransitGateway or route table
+// that does not exist (yet), which leaves the VPC disconnected.
+func (v *VPCCustomValidator) validateTransitGateway(ctx context.Context, vpc *galacticv1alpha.VPC, enforce bool) (admission.Warnings, field.ErrorList, error) {
+	association := vpc.Spec.TransitGateway
+	if association == nil {
+		return nil, nil, nil
+	}
+	var transitGateway galacticv1alpha.TransitGateway
+	if err := v.Get(ctx, types.NamespacedName{Name: association.Name}, &transitGateway); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		return admission.Warnings{fmt.Sprintf("TransitGateway %s does not exist", association.Name)}, nil, nil
+	}
+	routeTable := transitgateway.RouteTable(&transitGateway, association.RouteTable)
+	if routeTable == nil {
+		return admission.Warnings{fmt.Sprintf("TransitGateway %s has no route table %s", association.Name, association.RouteTable)}, nil, nil
+	}
+
+	permitted, err := transitgateway.Permitted(ctx, v.Client, routeTable, vpc.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if permitted {
+		return nil, nil, nil
+	}
+	message := fmt.Sprintf("route table %s of TransitGateway %s does not select namespace %s",
+		association.RouteTable, association.Name, vpc.Namespace)
+	if !enforce {
+		return admission.Warnings{message}, nil, nil
+	}
+	return nil, field.ErrorList{field.Forbidden(field.NewPath("spec", "transitGateway", "routeTable"), message)}, nil
+}
+
+// validateRemovedNetworks rejects the removal of networks that still contain
+// addresses of VPCAttachments referencing the VPC.
+func (v *VPCCustomValidator) validateRemovedNetworks(ctx context.Context, oldVPC, vpc *galacticv1alpha.VPC) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+
+	networks := parseNetworks(vpc.Spec.Networks)
+	removedNetworks := make([]*net.IPNet, 0, len(oldVPC.Spec.Networks))
+	for _, oldNetwork := range parseNetworks(oldVPC.Spec.Networks) {
+		if !slices.ContainsFunc(networks, func(network *net.IPNet) bool {
+			return network.String() == oldNetwork.String()
+		}) {
+			removedNetworks = append(removedNetworks, oldNetwork)
+		}
+	}
+	if len(removedNetworks) == 0 {
+		return nil, nil
+	}
+
+	var vpcAttachments galacticv1alpha.VPCAttachmentList
+	if err := v.List(ctx, &vpcAttachments, client.MatchingFields{
+		fieldindex.VPCAttachmentVPC: fieldindex.VPCKey(vpc.Namespace, vpc.Name),
+	}); err != nil {
+		return nil, err
+	}
+	for _, vpcAttachment := range vpcAttachments.Items {
+		for _, address := range cniconfig.InterfaceAddresses(vpcAttachment) {
+			ip, _, err := cniconfig.ParseAddress(address)
+			if err != nil || networkContains(networks, ip) {
+				continue
+			}
+			for _, removedNetwork := range removedNetworks {
+				if removedNetwork.Contains(ip) {
+					allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "networks"),
+						fmt.Sprintf("network %q is still used by VPCAttachment %s/%s (address %s)",
+							removedNetwork.String(), vpcAttachment.Namespace, vpcAttachment.Name, address)))
+				}
+			}
+		}
+	}
+
+	return allErrs, nil
+}
+
+// parseNetworks returns the networks that parse successfully, skipping
+// invalid entries. They are parsed like validateVPCNetworks does.
+func parseNetworks(networks []string) []*net.IPNet {
+	ipNets := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		ipNet, err := cniconfig.ParseNetwork(network)
+		if err != nil {
+			continue
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets
+}
+
+func networkContains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
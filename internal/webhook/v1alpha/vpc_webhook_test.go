@@ -0,0 +1,213 @@
+package v1alpha
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("VPC Webhook", func() {
+	var validator VPCCustomValidator
+
+	BeforeEach(func() {
+		validator = VPCCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+	})
+
+	vpcWithNetworks := func(networks ...string) *galacticv1alpha.VPC {
+		return &galacticv1alpha.VPC{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-vpc",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCSpec{
+				Networks: networks,
+			},
+		}
+	}
+
+	Context("When creating a VPC", func() {
+		It("should admit valid IPv4 and IPv6 networks", func() {
+			vpc := vpcWithNetworks("10.1.1.0/24", "10.1.2.0/24", "2001:10:1:1::/64")
+			Expect(validator.ValidateCreate(ctx, vpc)).Error().NotTo(HaveOccurred())
+		})
+
+		DescribeTable("should reject invalid networks",
+			func(networks ...string) {
+				vpc := vpcWithNetworks(networks...)
+				Expect(validator.ValidateCreate(ctx, vpc)).Error().To(HaveOccurred())
+			},
+			Entry("not a CIDR", "not-a-cidr"),
+			Entry("missing prefix length", "10.1.1.0"),
+			Entry("host bits set", "10.1.1.1/24"),
+			Entry("IPv4-mapped IPv6 notation", "::ffff:10.1.1.0/120"),
+			Entry("duplicate networks", "10.1.1.0/24", "10.1.1.0/24"),
+			Entry("duplicate networks in different notation", "2001:10:1:1::/64", "2001:10:1:1:0::/64"),
+			Entry("overlapping networks", "10.1.0.0/16", "10.1.1.0/24"),
+			Entry("overlapping IPv6 networks", "2001:10:1:1::/64", "2001:10::/32"),
+		)
+
+		DescribeTable("should validate the requested identifier",
+			func(requested string, valid bool) {
+				vpc := vpcWithNetworks("10.1.1.0/24")
+				vpc.Spec.Identifier = requested
+				if valid {
+					Expect(validator.ValidateCreate(ctx, vpc)).Error().NotTo(HaveOccurred())
+				} else {
+					Expect(validator.ValidateCreate(ctx, vpc)).Error().To(HaveOccurred())
+				}
+			},
+			Entry("canonical identifier", "f5b6726c782b", true),
+			Entry("short identifier", "3039", true),
+			Entry("special value", "ffffffffffff", false),
+			Entry("not hexadecimal", "vpc-1", false),
+		)
+
+		It("should reject changing the requested identifier", func() {
+			oldVPC := vpcWithNetworks("10.1.1.0/24")
+			oldVPC.Spec.Identifier = "f5b6726c782b"
+			vpc := oldVPC.DeepCopy()
+			Expect(validator.ValidateUpdate(ctx, oldVPC, vpc)).Error().NotTo(HaveOccurred())
+
+			vpc.Spec.Identifier = "f68a7a2a17d9"
+			Expect(validator.ValidateUpdate(ctx, oldVPC, vpc)).Error().To(HaveOccurred())
+		})
+	})
+
+	Context("When associating a VPC with a TransitGateway", func() {
+		var transitGateway *galacticv1alpha.TransitGateway
+
+		BeforeEach(func() {
+			transitGateway = &galacticv1alpha.TransitGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "vpc-hub"},
+				Spec: galacticv1alpha.TransitGatewaySpec{
+					RouteTables: []galacticv1alpha.TransitGatewayRouteTable{
+						{Name: "default", NamespaceSelector: &metav1.LabelSelector{}},
+						{Name: "restricted", NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"kubernetes.io/metadata.name": "other"},
+						}},
+						{Name: "closed"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, transitGateway)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, transitGateway)).To(Succeed())
+		})
+
+		DescribeTable("should warn about associations without effect",
+			func(name, routeTable string, warn bool) {
+				vpc := vpcWithNetworks("10.1.1.0/24")
+				vpc.Spec.TransitGateway = &galacticv1alpha.VPCTransitGatewayAssociation{Name: name, RouteTable: routeTable}
+				warnings, err := validator.ValidateCreate(ctx, vpc)
+				Expect(err).NotTo(HaveOccurred())
+				if warn {
+					Expect(warnings).To(HaveLen(1))
+				} else {
+					Expect(warnings).To(BeEmpty())
+				}
+			},
+			Entry("existing route table", "vpc-hub", "default", false),
+			Entry("missing route table", "vpc-hub", "spokes", true),
+			Entry("missing TransitGateway", "other-hub", "default", true),
+		)
+
+		DescribeTable("should reject associations the route table does not permit",
+			func(routeTable string) {
+				vpc := vpcWithNetworks("10.1.1.0/24")
+				vpc.Spec.TransitGateway = &galacticv1alpha.VPCTransitGatewayAssociation{Name: "vpc-hub", RouteTable: routeTable}
+				Expect(validator.ValidateCreate(ctx, vpc)).Error().To(MatchError(ContainSubstring("spec.transitGateway.routeTable")))
+
+				By("rejecting the association when updating a VPC")
+				oldVPC := vpcWithNetworks("10.1.1.0/24")
+				Expect(validator.ValidateUpdate(ctx, oldVPC, vpc)).Error().To(HaveOccurred())
+
+				By("only warning about an unchanged association")
+				warnings, err := validator.ValidateUpdate(ctx, vpc.DeepCopy(), vpc)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(warnings).To(HaveLen(1))
+			},
+			Entry("route table selecting other namespaces", "restricted"),
+			Entry("route table without namespace selector", "closed"),
+		)
+	})
+
+	Context("When updating a VPC that has attachments", func() {
+		var (
+			vpc           *galacticv1alpha.VPC
+			vpcAttachment *galacticv1alpha.VPCAttachment
+		)
+
+		BeforeEach(func() {
+			vpc = vpcWithNetworks("10.1.1.0/24", "10.1.2.0/24")
+			Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+
+			vpcAttachment = &galacticv1alpha.VPCAttachment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-vpcattachment",
+					Namespace: "default",
+				},
+				Spec: galacticv1alpha.VPCAttachmentSpec{
+					VPC: corev1.ObjectReference{
+						APIVersion: "galactic.datumapis.com/v1alpha",
+						Kind:       "VPC",
+						Name:       "test-vpc",
+						Namespace:  "default",
+					},
+					Interface: galacticv1alpha.VPCAttachmentInterface{
+						Name: "galactic0",
+						Addresses: []string{
+							"10.1.1.1/24",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vpcAttachment)).To(Succeed())
+
+			// The VPCAttachments of the VPC are looked up through the index of the cache
+			validator.Client = cachedClient
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, vpcAttachment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, vpc)).To(Succeed())
+		})
+
+		It("should allow removing an unused network", func() {
+			oldVPC := vpcWithNetworks("10.1.1.0/24", "10.1.2.0/24")
+			newVPC := vpcWithNetworks("10.1.1.0/24")
+			Expect(validator.ValidateUpdate(ctx, oldVPC, newVPC)).Error().NotTo(HaveOccurred())
+		})
+
+		It("should allow replacing a used network with a network that still contains its addresses", func() {
+			oldVPC := vpcWithNetworks("10.1.1.0/24")
+			newVPC := vpcWithNetworks("10.1.0.0/16")
+			Expect(validator.ValidateUpdate(ctx, oldVPC, newVPC)).Error().NotTo(HaveOccurred())
+		})
+
+		It("should reject removing a network still used by an attachment", func() {
+			oldVPC := vpcWithNetworks("10.1.1.0/24", "10.1.2.0/24")
+			newVPC := vpcWithNetworks("10.1.2.0/24")
+			Eventually(func() error {
+				_, err := validator.ValidateUpdate(ctx, oldVPC, newVPC)
+				return err
+			}).Should(MatchError(ContainSubstring("default/test-vpcattachment")))
+		})
+
+		It("should ignore attachments of other VPCs", func() {
+			oldVPC := vpcWithNetworks("10.1.1.0/24", "10.1.2.0/24")
+			oldVPC.Name = "other-vpc"
+			newVPC := vpcWithNetworks("10.1.2.0/24")
+			newVPC.Name = "other-vpc"
+			Expect(validator.ValidateUpdate(ctx, oldVPC, newVPC)).Error().NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,344 @@
+package v1alpha
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+	"github.com/datum-cloud/galactic-operator/internal/fieldindex"
+	"github.com/datum-cloud/galactic-operator/internal/identifier"
+	"github.com/datum-cloud/galactic-operator/internal/ipam"
+)
+
+// nolint:unused
+var vpcattachmentlog = logf.Log.WithName("vpcattachment-resource")
+
+func SetupVPCAttachmentWebhookWithManager(mgr ctrl.Manager, rejectAddressConflicts bool) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&galacticv1alpha.VPCAttachment{}).
+		WithValidator(&VPCAttachmentCustomValidator{
+			Client:                 mgr.GetClient(),
+			Scheme:                 mgr.GetScheme(),
+			RejectAddressConflicts: rejectAddressConflicts,
+		}).
+		WithDefaulter(&VPCAttachmentCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-galactic-datumapis-com-v1alpha-vpcattachment,mutating=true,failurePolicy=fail,sideEffects=None,groups=galactic.datumapis.com,resources=vpcattachments,verbs=create;update,versions=v1alpha,name=mvpcattachment-v1alpha.kb.io,admissionReviewVersions=v1
+
+type VPCAttachmentCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &VPCAttachmentCustomDefaulter{}
+
+// Default completes the VPC reference: VPCs are looked up in the namespace of
+// the VPCAttachment unless another one is given.
+func (d *VPCAttachmentCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	vpcAttachment, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return fmt.Errorf("expected a VPCAttachment object but got %T", obj)
+	}
+
+	if vpcAttachment.Spec.VPC.Namespace == "" {
+		vpcAttachment.Spec.VPC.Namespace = vpcAttachment.Namespace
+	}
+	if vpcAttachment.Spec.VPC.Kind == "" {
+		vpcAttachment.Spec.VPC.Kind = "VPC"
+	}
+	if vpcAttachment.Spec.VPC.APIVersion == "" {
+		vpcAttachment.Spec.VPC.APIVersion = galacticv1alpha.GroupVersion.String()
+	}
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-galactic-datumapis-com-v1alpha-vpcattachment,mutating=false,failurePolicy=fail,sideEffects=None,groups=galactic.datumapis.com,resources=vpcattachments,verbs=create;update,versions=v1alpha,name=vvpcattachment-v1alpha.kb.io,admissionReviewVersions=v1
+
+type VPCAttachmentCustomValidator struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// RejectAddressConflicts enables rejecting addresses that are already in
+	// use by another VPCAttachment of the same VPC. Without it conflicts are
+	// only reported by the controller.
+	RejectAddressConflicts bool
+}
+
+var _ webhook.CustomValidator = &VPCAttachmentCustomValidator{}
+
+func (v *VPCAttachmentCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	vpcAttachment, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCAttachment object but got %T", obj)
+	}
+
+	return nil, v.validateVPCAttachment(ctx, vpcAttachment)
+}
+
+func (v *VPCAttachmentCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldVPCAttachment, ok := oldObj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCAttachment object for the oldObj but got %T", oldObj)
+	}
+	vpcAttachment, ok := newObj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCAttachment object for the newObj but got %T", newObj)
+	}
+
+	if allErrs := apivalidation.ValidateImmutableField(vpcAttachment.Spec.Identifier, oldVPCAttachment.Spec.Identifier,
+		field.NewPath("spec", "identifier")); len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPCAttachment").GroupKind(), vpcAttachment.Name, allErrs)
+	}
+
+	return nil, v.validateVPCAttachment(ctx, vpcAttachment)
+}
+
+func (v *VPCAttachmentCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	_, ok := obj.(*galacticv1alpha.VPCAttachment)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCAttachment object but got %T", obj)
+	}
+
+	return nil, nil
+}
+
+func (v *VPCAttachmentCustomValidator) validateVPCAttachment(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if err := cniconfig.ValidateInterfaceName(vpcAttachment.Spec.Interface.Name); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("interface", "name"), vpcAttachment.Spec.Interface.Name, err.Error()))
+	}
+
+	allErrs = append(allErrs, validateRequestedIdentifier(specPath.Child("identifier"), vpcAttachment.Spec.Identifier, identifier.MaxVPCAttachment)...)
+
+	allErrs = append(allErrs, validateDefaultRoute(specPath.Child("interface", "defaultRoute"), vpcAttachment.Spec.Interface.DefaultRoute)...)
+
+	for i, route := range vpcAttachment.Spec.Routes {
+		if _, _, err := cniconfig.ParseRoute(route); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("routes").Index(i), route, err.Error()))
+			continue
+		}
+		// Traffic cannot be spread across the VPCAttachment itself
+		for j, nextHop := range route.NextHops {
+			if isInterfaceAddress(vpcAttachment, nextHop.Via) {
+				allErrs = append(allErrs, field.Invalid(specPath.Child("routes").Index(i).Child("nextHops").Index(j).Child("via"),
+					nextHop.Via, "next hop must not be an address of the interface"))
+			}
+		}
+		// Addresses allocated by the controller are only checked when rendering the CNI configuration
+		if route.Source != "" && len(vpcAttachment.Spec.Interface.Addresses) > 0 && !isInterfaceAddress(vpcAttachment, route.Source) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("routes").Index(i).Child("source"),
+				route.Source, "source must be an address of the interface"))
+		}
+	}
+
+	for i, rule := range vpcAttachment.Spec.Rules {
+		if _, _, err := cniconfig.ParseRule(rule); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("rules").Index(i), rule, err.Error()))
+		}
+	}
+
+	vpcPath := specPath.Child("vpc")
+	allErrs = append(allErrs, validateVPCReference(vpcPath, vpcAttachment.Spec.VPC)...)
+	vpcNamespacedName := types.NamespacedName{
+		Namespace: vpcAttachment.Spec.VPC.Namespace,
+		Name:      vpcAttachment.Spec.VPC.Name,
+	}
+	if vpcNamespacedName.Namespace == "" {
+		vpcNamespacedName.Namespace = vpcAttachment.Namespace
+	}
+	if vpcNamespacedName.Name != "" {
+		var vpc galacticv1alpha.VPC
+		if err := v.Get(ctx, vpcNamespacedName, &vpc); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			allErrs = append(allErrs, field.NotFound(vpcPath, vpcNamespacedName.String()))
+		} else {
+			allErrs = append(allErrs, validateAddressesInVPC(vpcAttachment, &vpc)...)
+		}
+	}
+
+	if v.RejectAddressConflicts {
+		conflictErrs, err := v.validateAddressConflicts(ctx, vpcAttachment)
+		if err != nil {
+			return err
+		}
+		allErrs = append(allErrs, conflictErrs...)
+	}
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPCAttachment").GroupKind(), vpcAttachment.Name, allErrs)
+	}
+	return nil
+}
+
+// validateVPCReference rejects references to anything but a VPC. Empty
+// fields are filled in by the defaulter.
+func validateVPCReference(path *field.Path, vpc corev1.ObjectReference) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if vpc.Kind != "" && vpc.Kind != "VPC" {
+		allErrs = append(allErrs, field.NotSupported(path.Child("kind"), vpc.Kind, []string{"VPC"}))
+	}
+	if vpc.APIVersion != "" && vpc.APIVersion != galacticv1alpha.GroupVersion.String() {
+		allErrs = append(allErrs, field.NotSupported(path.Child("apiVersion"), vpc.APIVersion,
+			[]string{galacticv1alpha.GroupVersion.String()}))
+	}
+	if vpc.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "name of the VPC is required"))
+	}
+
+	return allErrs
+}
+
+// validateAddressesInVPC checks that every interface address and address
+// range parses and lies within one of the networks of the VPC.
+func validateAddressesInVPC(vpcAttachment *galacticv1alpha.VPCAttachment, vpc *galacticv1alpha.VPC) field.ErrorList {
+	var allErrs field.ErrorList
+	addressesPath := field.NewPath("spec", "interface", "addresses")
+
+	vpcNetworks := parseNetworks(vpc.Spec.Networks)
+	seen := make(map[string]struct{}, len(vpcAttachment.Spec.Interface.Addresses))
+	for i, address := range vpcAttachment.Spec.Interface.Addresses {
+		ip, network, err := cniconfig.ParseAddress(address)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(addressesPath.Index(i), address, err.Error()))
+			continue
+		}
+		if _, exists := seen[ip.String()]; exists {
+			allErrs = append(allErrs, field.Duplicate(addressesPath.Index(i), address))
+			continue
+		}
+		seen[ip.String()] = struct{}{}
+
+		if !networkContainsNetwork(vpcNetworks, network) {
+			allErrs = append(allErrs, field.Invalid(addressesPath.Index(i), address,
+				fmt.Sprintf("address is not within any network of VPC %s/%s", vpc.Namespace, vpc.Name)))
+		}
+	}
+
+	addressRangesPath := field.NewPath("spec", "interface", "addressRanges")
+	validRanges := true
+	for i, addressRange := range vpcAttachment.Spec.Interface.AddressRanges {
+		_, network, err := net.ParseCIDR(addressRange)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(addressRangesPath.Index(i), addressRange, "must be a network in CIDR notation"))
+			validRanges = false
+			continue
+		}
+		if !networkContainsNetwork(vpcNetworks, network) {
+			allErrs = append(allErrs, field.Invalid(addressRangesPath.Index(i), addressRange,
+				fmt.Sprintf("address range is not within any network of VPC %s/%s", vpc.Namespace, vpc.Name)))
+		}
+	}
+
+	if index := vpcAttachment.Spec.Interface.AddressIndex; index != nil && validRanges {
+		ranges := vpcAttachment.Spec.Interface.AddressRanges
+		if len(ranges) == 0 {
+			ranges = vpc.Spec.Networks
+		}
+		if !ipam.HasNth(ranges, int(*index)) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "interface", "addressIndex"), *index,
+				fmt.Sprintf("index is beyond the addresses of the address ranges %v", ranges)))
+		}
+	}
+
+	defaultRoutePath := field.NewPath("spec", "interface", "defaultRoute")
+	for i, gateway := range vpcAttachment.Spec.Interface.DefaultRoute {
+		ip := net.ParseIP(gateway)
+		if ip != nil && !networkContains(vpcNetworks, ip) {
+			allErrs = append(allErrs, field.Invalid(defaultRoutePath.Index(i), gateway,
+				fmt.Sprintf("gateway is not within any network of VPC %s/%s", vpc.Namespace, vpc.Name)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateDefaultRoute checks that the default route gateways are addresses
+// of distinct address families.
+func validateDefaultRoute(path *field.Path, gateways []string) field.ErrorList {
+	var allErrs field.ErrorList
+
+	seenFamilies := make(map[bool]struct{}, len(gateways))
+	for i, gateway := range gateways {
+		ip := net.ParseIP(gateway)
+		if ip == nil {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), gateway, "must be an IPv4 or IPv6 address"))
+			continue
+		}
+		isIPv4 := ip.To4() != nil
+		if _, exists := seenFamilies[isIPv4]; exists {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), gateway, "only one gateway per address family is allowed"))
+			continue
+		}
+		seenFamilies[isIPv4] = struct{}{}
+	}
+
+	return allErrs
+}
+
+// validateAddressConflicts rejects interface addresses that are already in use
+// by another VPCAttachment of the same VPC.
+func (v *VPCAttachmentCustomValidator) validateAddressConflicts(ctx context.Context, vpcAttachment *galacticv1alpha.VPCAttachment) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+	addressesPath := field.NewPath("spec", "interface", "addresses")
+
+	for i, address := range vpcAttachment.Spec.Interface.Addresses {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			continue
+		}
+		var vpcAttachments galacticv1alpha.VPCAttachmentList
+		if err := v.List(ctx, &vpcAttachments, client.MatchingFields{
+			fieldindex.VPCAttachmentAddress: fieldindex.VPCAddressKey(vpcAttachment.Spec.VPC.Namespace, vpcAttachment.Spec.VPC.Name, ip),
+		}); err != nil {
+			return nil, err
+		}
+		for _, other := range vpcAttachments.Items {
+			if other.Namespace == vpcAttachment.Namespace && other.Name == vpcAttachment.Name {
+				continue
+			}
+			allErrs = append(allErrs, field.Forbidden(addressesPath.Index(i),
+				fmt.Sprintf("address %s is already in use by VPCAttachment %s/%s", ip, other.Namespace, other.Name)))
+			break
+		}
+	}
+
+	return allErrs, nil
+}
+
+func networkContainsNetwork(networks []*net.IPNet, inner *net.IPNet) bool {
+	for _, network := range networks {
+		if cniconfig.NetworkContains(network, inner) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInterfaceAddress reports whether ip is one of the addresses requested for
+// the interface of the VPCAttachment.
+func isInterfaceAddress(vpcAttachment *galacticv1alpha.VPCAttachment, ip string) bool {
+	return slices.ContainsFunc(vpcAttachment.Spec.Interface.Addresses, func(address string) bool {
+		interfaceIP, _, err := net.ParseCIDR(address)
+		return err == nil && interfaceIP.Equal(net.ParseIP(ip))
+	})
+}
@@ -0,0 +1,266 @@
+package v1alpha
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("VPCAttachment Webhook", func() {
+	var (
+		vpc       *galacticv1alpha.VPC
+		validator VPCAttachmentCustomValidator
+	)
+
+	BeforeEach(func() {
+		vpc = &galacticv1alpha.VPC{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "attachment-vpc",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCSpec{
+				Networks: []string{
+					"10.1.1.0/24",
+					"2001:10:1:1::/64",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+
+		validator = VPCAttachmentCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, vpc)).To(Succeed())
+	})
+
+	vpcAttachment := func(vpcName, interfaceName string, addresses []string, routes []galacticv1alpha.VPCAttachmentRoute) *galacticv1alpha.VPCAttachment {
+		return &galacticv1alpha.VPCAttachment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-vpcattachment",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCAttachmentSpec{
+				VPC: corev1.ObjectReference{
+					APIVersion: "galactic.datumapis.com/v1alpha",
+					Kind:       "VPC",
+					Name:       vpcName,
+					Namespace:  "default",
+				},
+				Interface: galacticv1alpha.VPCAttachmentInterface{
+					Name:      interfaceName,
+					Addresses: addresses,
+				},
+				Routes: routes,
+			},
+		}
+	}
+
+	It("should admit a VPCAttachment with addresses within the VPC networks", func() {
+		obj := vpcAttachment("attachment-vpc", "galactic0",
+			[]string{"10.1.1.1/24", "2001:10:1:1::1/64"},
+			[]galacticv1alpha.VPCAttachmentRoute{
+				{Destination: "192.168.1.0/24", Via: "10.1.1.1"},
+				{Destination: "2001:1::/64", Via: "2001:10:1:1::1"},
+				{Destination: "192.168.2.0/24"},
+				{Destination: "0.0.0.0/0", NextHops: []galacticv1alpha.VPCAttachmentNextHop{
+					{Via: "10.1.1.2", Weight: 2},
+					{Via: "10.1.1.3", Weight: 1},
+				}},
+			})
+		Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		Expect(validator.ValidateUpdate(ctx, obj, obj)).Error().NotTo(HaveOccurred())
+	})
+
+	It("should reject a VPCAttachment referencing a missing VPC", func() {
+		obj := vpcAttachment("missing-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+		Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+	})
+
+	DescribeTable("should reject invalid addresses",
+		func(addresses ...string) {
+			obj := vpcAttachment("attachment-vpc", "galactic0", addresses, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+		},
+		Entry("address without prefix length", "10.1.1.1"),
+		Entry("address outside of the VPC networks", "10.1.2.1/24"),
+		Entry("address with a prefix larger than the VPC network", "10.1.1.1/16"),
+		Entry("IPv6 address outside of the VPC networks", "2001:10:1:2::1/64"),
+		Entry("duplicate address", "10.1.1.1/24", "10.1.1.1/24"),
+	)
+
+	DescribeTable("should validate address ranges",
+		func(addressRange string, valid bool) {
+			obj := vpcAttachment("attachment-vpc", "galactic0", nil, nil)
+			obj.Spec.Interface.AddressRanges = []string{addressRange}
+			if valid {
+				Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+			} else {
+				Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.interface.addressRanges[0]")))
+			}
+		},
+		Entry("range within the VPC networks", "10.1.1.192/26", true),
+		Entry("IPv6 range within the VPC networks", "2001:10:1:1::/112", true),
+		Entry("range is not a CIDR", "10.1.1.192", false),
+		Entry("range outside of the VPC networks", "10.1.2.0/26", false),
+		Entry("range larger than the VPC network", "10.1.0.0/16", false),
+	)
+
+	DescribeTable("should validate the address index",
+		func(addressRanges []string, index int32, valid bool) {
+			obj := vpcAttachment("attachment-vpc", "galactic0", nil, nil)
+			obj.Spec.Interface.AddressRanges = addressRanges
+			obj.Spec.Interface.AddressIndex = &index
+			if valid {
+				Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+			} else {
+				Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.interface.addressIndex")))
+			}
+		},
+		Entry("index within the VPC networks", nil, int32(253), true),
+		Entry("index beyond the IPv4 network of the VPC", nil, int32(254), false),
+		Entry("index within the address ranges", []string{"10.1.1.192/26"}, int32(61), true),
+		Entry("index beyond the address ranges", []string{"10.1.1.192/26"}, int32(62), false),
+	)
+
+	DescribeTable("should reject invalid routes",
+		func(route galacticv1alpha.VPCAttachmentRoute) {
+			obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"},
+				[]galacticv1alpha.VPCAttachmentRoute{route})
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+		},
+		Entry("destination is not a CIDR", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0", Via: "10.1.1.1"}),
+		Entry("via is not an address", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1"}),
+		Entry("via is in another address family", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "2001:10:1:1::1"}),
+		Entry("via and next hops", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24", Via: "10.1.1.2",
+			NextHops: []galacticv1alpha.VPCAttachmentNextHop{{Via: "10.1.1.3"}}}),
+		Entry("next hop is an address of the interface", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24",
+			NextHops: []galacticv1alpha.VPCAttachmentNextHop{{Via: "10.1.1.1"}, {Via: "10.1.1.2"}}}),
+		Entry("source is not an address of the interface", galacticv1alpha.VPCAttachmentRoute{Destination: "192.168.1.0/24",
+			Via: "10.1.1.2", Source: "10.1.1.9"}),
+	)
+
+	It("should admit policy routing rules and routes in other tables", func() {
+		obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"},
+			[]galacticv1alpha.VPCAttachmentRoute{
+				{Destination: "0.0.0.0/0", Via: "10.1.1.254", Table: 100, Source: "10.1.1.1", Metric: 10},
+			})
+		obj.Spec.Rules = []galacticv1alpha.VPCAttachmentRule{
+			{From: "10.1.1.1/32", Priority: 100, Table: 100},
+		}
+		Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+	})
+
+	DescribeTable("should reject invalid rules",
+		func(rule galacticv1alpha.VPCAttachmentRule) {
+			obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+			obj.Spec.Rules = []galacticv1alpha.VPCAttachmentRule{rule}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.rules[0]")))
+		},
+		Entry("rule without networks", galacticv1alpha.VPCAttachmentRule{Table: 100}),
+		Entry("from is not a CIDR", galacticv1alpha.VPCAttachmentRule{From: "10.1.1.1", Table: 100}),
+		Entry("from and to in different address families", galacticv1alpha.VPCAttachmentRule{From: "10.1.1.1/32", To: "2001:1::/64", Table: 100}),
+	)
+
+	DescribeTable("should reject invalid interface names",
+		func(interfaceName string) {
+			obj := vpcAttachment("attachment-vpc", interfaceName, []string{"10.1.1.1/24"}, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+		},
+		Entry("empty name", ""),
+		Entry("name longer than 15 characters", "galactic0123456789"),
+		Entry("name containing a slash", "galactic/0"),
+	)
+
+	It("should admit default route gateways within the VPC networks", func() {
+		obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24", "2001:10:1:1::1/64"}, nil)
+		obj.Spec.Interface.MAC = "c2:b0:57:49:47:f1"
+		obj.Spec.Interface.DefaultRoute = []string{"10.1.1.254", "2001:10:1:1::fe"}
+		Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+	})
+
+	DescribeTable("should reject invalid default routes",
+		func(gateways ...string) {
+			obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24", "2001:10:1:1::1/64"}, nil)
+			obj.Spec.Interface.DefaultRoute = gateways
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+		},
+		Entry("gateway is not an address", "10.1.1"),
+		Entry("gateway outside of the VPC networks", "10.1.2.254"),
+		Entry("two gateways of the same address family", "10.1.1.253", "10.1.1.254"),
+	)
+
+	It("should validate the requested identifier and keep it immutable", func() {
+		obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+		obj.Spec.Identifier = "e513"
+		Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+		updated := obj.DeepCopy()
+		updated.Spec.Identifier = "e514"
+		Expect(validator.ValidateUpdate(ctx, obj, updated)).Error().To(HaveOccurred())
+
+		obj.Spec.Identifier = "ffff"
+		Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+	})
+
+	It("should default the VPC reference to a VPC in the same namespace", func() {
+		obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+		obj.Spec.VPC = corev1.ObjectReference{Name: "attachment-vpc"}
+
+		defaulter := VPCAttachmentCustomDefaulter{}
+		Expect(defaulter.Default(ctx, obj)).To(Succeed())
+		Expect(obj.Spec.VPC).To(Equal(corev1.ObjectReference{
+			APIVersion: "galactic.datumapis.com/v1alpha",
+			Kind:       "VPC",
+			Name:       "attachment-vpc",
+			Namespace:  "default",
+		}))
+		Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+	})
+
+	It("should reject references to other kinds", func() {
+		obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+		obj.Spec.VPC.Kind = "Service"
+		Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.vpc.kind")))
+
+		obj = vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+		obj.Spec.VPC.APIVersion = "v1"
+		Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.vpc.apiVersion")))
+	})
+
+	Context("When another VPCAttachment already uses an address", func() {
+		var existing *galacticv1alpha.VPCAttachment
+
+		BeforeEach(func() {
+			existing = vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+			existing.Name = "existing-vpcattachment"
+			Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, existing)).To(Succeed())
+		})
+
+		It("should only reject the duplicate address when conflicts are rejected", func() {
+			obj := vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.1/24"}, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			validator.Client = cachedClient
+			validator.RejectAddressConflicts = true
+			Eventually(func() error {
+				_, err := validator.ValidateCreate(ctx, obj)
+				return err
+			}).Should(MatchError(ContainSubstring("default/existing-vpcattachment")))
+
+			obj = vpcAttachment("attachment-vpc", "galactic0", []string{"10.1.1.2/24"}, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package v1alpha
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/peering"
+)
+
+// nolint:unused
+var vpcpeeringlog = logf.Log.WithName("vpcpeering-resource")
+
+func SetupVPCPeeringWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&galacticv1alpha.VPCPeering{}).
+		WithValidator(&VPCPeeringCustomValidator{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-galactic-datumapis-com-v1alpha-vpcpeering,mutating=false,failurePolicy=fail,sideEffects=None,groups=galactic.datumapis.com,resources=vpcpeerings,verbs=create;update,versions=v1alpha,name=vvpcpeering-v1alpha.kb.io,admissionReviewVersions=v1
+
+type VPCPeeringCustomValidator struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+var _ webhook.CustomValidator = &VPCPeeringCustomValidator{}
+
+func (v *VPCPeeringCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	vpcPeering, ok := obj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCPeering object but got %T", obj)
+	}
+
+	allErrs, warnings, err := v.validateVPCPeering(ctx, vpcPeering)
+	if err != nil {
+		return nil, err
+	}
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPCPeering").GroupKind(), vpcPeering.Name, allErrs)
+	}
+
+	return warnings, nil
+}
+
+func (v *VPCPeeringCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldVPCPeering, ok := oldObj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCPeering object for the oldObj but got %T", oldObj)
+	}
+	vpcPeering, ok := newObj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCPeering object for the newObj but got %T", newObj)
+	}
+
+	// Connecting other VPCs takes a new VPCPeering, so that the peer accepts
+	// the peering of exactly these VPCs
+	allErrs := apivalidation.ValidateImmutableField(vpcPeering.Spec, oldVPCPeering.Spec, field.NewPath("spec"))
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPCPeering").GroupKind(), vpcPeering.Name, allErrs)
+	}
+
+	return nil, nil
+}
+
+func (v *VPCPeeringCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	_, ok := obj.(*galacticv1alpha.VPCPeering)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCPeering object but got %T", obj)
+	}
+
+	return nil, nil
+}
+
+// validateVPCPeering checks that the VPCPeering connects an existing VPC to
+// another VPC whose networks do not overlap with its own. The peer VPC may be
+// created later, which is only reported as a warning.
+func (v *VPCPeeringCustomValidator) validateVPCPeering(ctx context.Context, vpcPeering *galacticv1alpha.VPCPeering) (field.ErrorList, admission.Warnings, error) {
+	vpcName, peerVPCName := peering.VPC(vpcPeering), peering.PeerVPC(vpcPeering)
+	if vpcName == peerVPCName {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "peerVPC"), peerVPCName.String(), "a VPC cannot peer with itself")}, nil, nil
+	}
+
+	var vpc galacticv1alpha.VPC
+	if err := v.Get(ctx, vpcName, &vpc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		return field.ErrorList{field.NotFound(field.NewPath("spec", "vpc"), vpcPeering.Spec.VPC)}, nil, nil
+	}
+
+	var peerVPC galacticv1alpha.VPC
+	if err := v.Get(ctx, peerVPCName, &peerVPC); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		return nil, admission.Warnings{fmt.Sprintf("peer VPC %s does not exist yet", peerVPCName)}, nil
+	}
+
+	if network, peerNetwork, overlap := peering.OverlappingNetworks(vpc.Spec.Networks, peerVPC.Spec.Networks); overlap {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "peerVPC"), peerVPCName.String(),
+			fmt.Sprintf("network %q of VPC %s overlaps with network %q of the peer VPC", network, vpcName, peerNetwork))}, nil, nil
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,97 @@
+package v1alpha
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("VPCPeering Webhook", func() {
+	var (
+		vpcs      []*galacticv1alpha.VPC
+		validator VPCPeeringCustomValidator
+	)
+
+	BeforeEach(func() {
+		vpcs = []*galacticv1alpha.VPC{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "peering-vpc-a", Namespace: "default"},
+				Spec:       galacticv1alpha.VPCSpec{Networks: []string{"10.11.0.0/16", "2001:10:11::/48"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "peering-vpc-b", Namespace: "default"},
+				Spec:       galacticv1alpha.VPCSpec{Networks: []string{"10.12.0.0/16", "2001:10:12::/48"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "peering-vpc-c", Namespace: "default"},
+				Spec:       galacticv1alpha.VPCSpec{Networks: []string{"10.11.128.0/24"}},
+			},
+		}
+		for _, vpc := range vpcs {
+			Expect(k8sClient.Create(ctx, vpc)).To(Succeed())
+		}
+
+		validator = VPCPeeringCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+	})
+
+	AfterEach(func() {
+		for _, vpc := range vpcs {
+			Expect(k8sClient.Delete(ctx, vpc)).To(Succeed())
+		}
+	})
+
+	vpcPeering := func(vpcName, peerName, peerNamespace string) *galacticv1alpha.VPCPeering {
+		return &galacticv1alpha.VPCPeering{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-vpcpeering",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCPeeringSpec{
+				VPC: vpcName,
+				PeerVPC: galacticv1alpha.VPCPeeringPeer{
+					Name:      peerName,
+					Namespace: peerNamespace,
+				},
+			},
+		}
+	}
+
+	Context("When creating a VPCPeering", func() {
+		It("should admit VPCs with disjoint networks", func() {
+			Expect(validator.ValidateCreate(ctx, vpcPeering("peering-vpc-a", "peering-vpc-b", ""))).Error().NotTo(HaveOccurred())
+		})
+
+		It("should warn about a peer VPC that does not exist yet", func() {
+			warnings, err := validator.ValidateCreate(ctx, vpcPeering("peering-vpc-a", "peering-vpc-d", "other"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+
+		DescribeTable("should reject invalid peerings",
+			func(vpcName, peerName, peerNamespace string) {
+				Expect(validator.ValidateCreate(ctx, vpcPeering(vpcName, peerName, peerNamespace))).Error().To(HaveOccurred())
+			},
+			Entry("peering with itself", "peering-vpc-a", "peering-vpc-a", ""),
+			Entry("peering with itself by namespace", "peering-vpc-a", "peering-vpc-a", "default"),
+			Entry("missing VPC", "peering-vpc-d", "peering-vpc-b", ""),
+			Entry("overlapping networks", "peering-vpc-a", "peering-vpc-c", ""),
+		)
+	})
+
+	Context("When updating a VPCPeering", func() {
+		It("should reject changing the peered VPCs", func() {
+			oldVPCPeering := vpcPeering("peering-vpc-a", "peering-vpc-b", "")
+			Expect(validator.ValidateUpdate(ctx, oldVPCPeering, oldVPCPeering.DeepCopy())).Error().NotTo(HaveOccurred())
+
+			updated := oldVPCPeering.DeepCopy()
+			updated.Spec.PeerVPC.Name = "peering-vpc-c"
+			Expect(validator.ValidateUpdate(ctx, oldVPCPeering, updated)).Error().To(HaveOccurred())
+		})
+	})
+})
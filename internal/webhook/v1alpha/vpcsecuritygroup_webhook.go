@@ -0,0 +1,122 @@
+package v1alpha
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+
+	"github.com/datum-cloud/galactic-operator/internal/cniconfig"
+)
+
+// nolint:unused
+var vpcsecuritygrouplog = logf.Log.WithName("vpcsecuritygroup-resource")
+
+func SetupVPCSecurityGroupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&galacticv1alpha.VPCSecurityGroup{}).
+		WithValidator(&VPCSecurityGroupCustomValidator{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-galactic-datumapis-com-v1alpha-vpcsecuritygroup,mutating=false,failurePolicy=fail,sideEffects=None,groups=galactic.datumapis.com,resources=vpcsecuritygroups,verbs=create;update,versions=v1alpha,name=vvpcsecuritygroup-v1alpha.kb.io,admissionReviewVersions=v1
+
+type VPCSecurityGroupCustomValidator struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+var _ webhook.CustomValidator = &VPCSecurityGroupCustomValidator{}
+
+func (v *VPCSecurityGroupCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	securityGroup, ok := obj.(*galacticv1alpha.VPCSecurityGroup)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCSecurityGroup object but got %T", obj)
+	}
+
+	return nil, validateVPCSecurityGroup(securityGroup)
+}
+
+func (v *VPCSecurityGroupCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	_, ok := oldObj.(*galacticv1alpha.VPCSecurityGroup)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCSecurityGroup object for the oldObj but got %T", oldObj)
+	}
+	securityGroup, ok := newObj.(*galacticv1alpha.VPCSecurityGroup)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCSecurityGroup object for the newObj but got %T", newObj)
+	}
+
+	return nil, validateVPCSecurityGroup(securityGroup)
+}
+
+func (v *VPCSecurityGroupCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	_, ok := obj.(*galacticv1alpha.VPCSecurityGroup)
+	if !ok {
+		return nil, fmt.Errorf("expected a VPCSecurityGroup object but got %T", obj)
+	}
+
+	return nil, nil
+}
+
+func validateVPCSecurityGroup(securityGroup *galacticv1alpha.VPCSecurityGroup) error {
+	allErrs := validateSecurityGroupRules(field.NewPath("spec", "ingress"), securityGroup.Spec.Ingress)
+	allErrs = append(allErrs, validateSecurityGroupRules(field.NewPath("spec", "egress"), securityGroup.Spec.Egress)...)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(galacticv1alpha.GroupVersion.WithKind("VPCSecurityGroup").GroupKind(), securityGroup.Name, allErrs)
+	}
+	return nil
+}
+
+// validateSecurityGroupRules checks that every peer sets exactly one of its
+// fields and that ports are valid ranges of a protocol with ports.
+func validateSecurityGroupRules(path *field.Path, rules []galacticv1alpha.VPCSecurityGroupRule) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, rule := range rules {
+		rulePath := path.Index(i)
+		for j, peer := range rule.Peers {
+			peerPath := rulePath.Child("peers").Index(j)
+			switch {
+			case peer.CIDR == "" && peer.VPCAttachmentSelector == nil:
+				allErrs = append(allErrs, field.Required(peerPath, "one of cidr and vpcAttachmentSelector must be set"))
+			case peer.CIDR != "" && peer.VPCAttachmentSelector != nil:
+				allErrs = append(allErrs, field.Forbidden(peerPath.Child("vpcAttachmentSelector"), "cannot be combined with cidr"))
+			case peer.CIDR != "":
+				if _, err := cniconfig.ParseNetwork(peer.CIDR); err != nil {
+					allErrs = append(allErrs, field.Invalid(peerPath.Child("cidr"), peer.CIDR, err.Error()))
+				}
+			default:
+				allErrs = append(allErrs, metav1validation.ValidateLabelSelector(peer.VPCAttachmentSelector,
+					metav1validation.LabelSelectorValidationOptions{}, peerPath.Child("vpcAttachmentSelector"))...)
+			}
+		}
+
+		if len(rule.Ports) == 0 {
+			continue
+		}
+		switch rule.Protocol {
+		case galacticv1alpha.VPCSecurityGroupProtocolTCP, galacticv1alpha.VPCSecurityGroupProtocolUDP, galacticv1alpha.VPCSecurityGroupProtocolSCTP:
+		default:
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("protocol"), rule.Protocol, "ports require the TCP, UDP or SCTP protocol"))
+		}
+		for j, port := range rule.Ports {
+			if port.EndPort != 0 && port.EndPort < port.Port {
+				allErrs = append(allErrs, field.Invalid(rulePath.Child("ports").Index(j).Child("endPort"), port.EndPort,
+					fmt.Sprintf("must not be lower than port %d", port.Port)))
+			}
+		}
+	}
+	return allErrs
+}
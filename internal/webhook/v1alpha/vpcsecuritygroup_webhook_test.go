@@ -0,0 +1,82 @@
+package v1alpha
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	galacticv1alpha "github.com/datum-cloud/galactic-operator/api/v1alpha"
+)
+
+var _ = Describe("VPCSecurityGroup Webhook", func() {
+	var validator VPCSecurityGroupCustomValidator
+
+	BeforeEach(func() {
+		validator = VPCSecurityGroupCustomValidator{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+	})
+
+	securityGroupWithIngress := func(rules ...galacticv1alpha.VPCSecurityGroupRule) *galacticv1alpha.VPCSecurityGroup {
+		return &galacticv1alpha.VPCSecurityGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-securitygroup",
+				Namespace: "default",
+			},
+			Spec: galacticv1alpha.VPCSecurityGroupSpec{Ingress: rules},
+		}
+	}
+
+	Context("When creating a VPCSecurityGroup", func() {
+		It("should admit rules by network and by VPCAttachment", func() {
+			securityGroup := securityGroupWithIngress(
+				galacticv1alpha.VPCSecurityGroupRule{
+					Peers: []galacticv1alpha.VPCSecurityGroupPeer{
+						{CIDR: "10.1.0.0/16"},
+						{VPCAttachmentSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+					},
+					Protocol: galacticv1alpha.VPCSecurityGroupProtocolTCP,
+					Ports:    []galacticv1alpha.VPCSecurityGroupPort{{Port: 80}, {Port: 8000, EndPort: 8080}},
+				},
+				galacticv1alpha.VPCSecurityGroupRule{Protocol: galacticv1alpha.VPCSecurityGroupProtocolICMP},
+			)
+			Expect(validator.ValidateCreate(ctx, securityGroup)).Error().NotTo(HaveOccurred())
+		})
+
+		DescribeTable("should reject invalid rules",
+			func(rule galacticv1alpha.VPCSecurityGroupRule) {
+				Expect(validator.ValidateCreate(ctx, securityGroupWithIngress(rule))).Error().To(HaveOccurred())
+			},
+			Entry("peer without fields", galacticv1alpha.VPCSecurityGroupRule{
+				Peers: []galacticv1alpha.VPCSecurityGroupPeer{{}},
+			}),
+			Entry("peer with both fields", galacticv1alpha.VPCSecurityGroupRule{
+				Peers: []galacticv1alpha.VPCSecurityGroupPeer{{
+					CIDR:                  "10.1.0.0/16",
+					VPCAttachmentSelector: &metav1.LabelSelector{},
+				}},
+			}),
+			Entry("invalid network", galacticv1alpha.VPCSecurityGroupRule{
+				Peers: []galacticv1alpha.VPCSecurityGroupPeer{{CIDR: "10.1.1.1/16"}},
+			}),
+			Entry("invalid selector", galacticv1alpha.VPCSecurityGroupRule{
+				Peers: []galacticv1alpha.VPCSecurityGroupPeer{{VPCAttachmentSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}},
+				}}},
+			}),
+			Entry("ports without protocol", galacticv1alpha.VPCSecurityGroupRule{
+				Ports: []galacticv1alpha.VPCSecurityGroupPort{{Port: 80}},
+			}),
+			Entry("ports of ICMP", galacticv1alpha.VPCSecurityGroupRule{
+				Protocol: galacticv1alpha.VPCSecurityGroupProtocolICMP,
+				Ports:    []galacticv1alpha.VPCSecurityGroupPort{{Port: 80}},
+			}),
+			Entry("reversed port range", galacticv1alpha.VPCSecurityGroupRule{
+				Protocol: galacticv1alpha.VPCSecurityGroupProtocolUDP,
+				Ports:    []galacticv1alpha.VPCSecurityGroupPort{{Port: 8080, EndPort: 8000}},
+			}),
+		)
+	})
+})
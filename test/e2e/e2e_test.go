@@ -463,9 +463,19 @@ var _ = Describe("Manager", Ordered, func() {
 						"-o", `jsonpath={.items[0].metadata.annotations.k8s\.v1\.cni\.cncf\.io/networks}`)
 					output, err := utils.Run(cmd)
 					g.Expect(err).NotTo(HaveOccurred())
-					expectedAnnotation := fmt.Sprintf("%s@%s", tc.name, tc.interfaceName)
-					g.Expect(output).To(Equal(expectedAnnotation),
-						fmt.Sprintf("pod for %s should have Multus annotation %q, got %q", tc.name, expectedAnnotation, output))
+					var networks []struct {
+						Name      string   `json:"name"`
+						Namespace string   `json:"namespace"`
+						Interface string   `json:"interface"`
+						IPs       []string `json:"ips"`
+					}
+					g.Expect(json.Unmarshal([]byte(output), &networks)).To(Succeed(),
+						fmt.Sprintf("pod for %s should have a JSON Multus annotation, got %q", tc.name, output))
+					g.Expect(networks).To(HaveLen(1))
+					g.Expect(networks[0].Name).To(Equal(tc.name))
+					g.Expect(networks[0].Namespace).To(Equal(testNamespace))
+					g.Expect(networks[0].Interface).To(Equal(tc.interfaceName))
+					g.Expect(networks[0].IPs).NotTo(BeEmpty())
 				}
 				Eventually(verifyMultusAnnotation, 2*time.Minute, 5*time.Second).Should(Succeed())
 			}